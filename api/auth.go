@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+// Authenticator adds the credentials to every request sent to the Kibana API
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// BasicAuth authenticates with a username:password pair (CLOUD_AUTH)
+type BasicAuth struct {
+	CloudAuth string
+}
+
+// Authenticate sets the Basic authorization header
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	username, password, err := utils.ParseTwoPartID(a.CloudAuth, "username", "password")
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
+
+// APIKeyAuth authenticates with an Elasticsearch API key.
+// The key can be given either as id:api_key or already base64 encoded.
+type APIKeyAuth struct {
+	APIKey string
+}
+
+// Authenticate sets the ApiKey authorization header
+func (a APIKeyAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "ApiKey "+EncodeAPIKey(a.APIKey))
+	return nil
+}
+
+// BearerAuth authenticates with a bearer token (e.g. an Elasticsearch service account token)
+type BearerAuth struct {
+	Token string
+}
+
+// Authenticate sets the Bearer authorization header
+func (a BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// EncodeAPIKey returns the base64 encoded form expected by the ApiKey authorization header.
+// Keys which are not in the id:api_key form are considered as already encoded.
+func EncodeAPIKey(apiKey string) string {
+	if !strings.Contains(apiKey, ":") {
+		return apiKey
+	}
+	return base64.StdEncoding.EncodeToString([]byte(apiKey))
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultPipelinesCacheTTL = 10 * time.Second
+
+// pipelinesCache keeps the pipelines list of every space for a short time.
+// Concurrent fetches of the same list are deduplicated: only the first caller hits Kibana,
+// the others wait for its result.
+type pipelinesCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*pipelinesCacheEntry
+}
+
+type pipelinesCacheEntry struct {
+	// done is closed once the fetch is over
+	done      chan struct{}
+	pipelines *LogstashPipelines
+	err       error
+	expires   time.Time
+}
+
+func newPipelinesCache(ttl time.Duration) *pipelinesCache {
+	return &pipelinesCache{
+		ttl:     ttl,
+		entries: make(map[string]*pipelinesCacheEntry),
+	}
+}
+
+// get returns the cached list for key, calling fetch if there is none (or if it expired).
+// Errors are never cached.
+func (pc *pipelinesCache) get(ctx context.Context, key string, fetch func() (*LogstashPipelines, error)) (*LogstashPipelines, error) {
+	for {
+		pc.mu.Lock()
+		entry, ok := pc.entries[key]
+		if ok {
+			select {
+			case <-entry.done:
+				if entry.err != nil || time.Now().After(entry.expires) {
+					ok = false
+				}
+			default:
+				// Fetch in flight, wait for it below
+			}
+		}
+		if !ok {
+			entry = &pipelinesCacheEntry{done: make(chan struct{})}
+			pc.entries[key] = entry
+			pc.mu.Unlock()
+
+			entry.pipelines, entry.err = fetch()
+			entry.expires = time.Now().Add(pc.ttl)
+			close(entry.done)
+
+			if entry.err != nil {
+				pc.invalidateEntry(key, entry)
+				return nil, entry.err
+			}
+			return entry.pipelines.copy(), nil
+		}
+		pc.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-entry.done:
+		}
+		if entry.err != nil {
+			if isContextError(entry.err) && ctx.Err() == nil {
+				// The caller which fetched the list was canceled (or timed out), not this one: fetch it again
+				continue
+			}
+			return nil, entry.err
+		}
+		return entry.pipelines.copy(), nil
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// invalidate drops the cached list for key, a fetch in flight won't be stored either
+func (pc *pipelinesCache) invalidate(key string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	delete(pc.entries, key)
+}
+
+// invalidateEntry drops entry only if it is still the one cached for key
+func (pc *pipelinesCache) invalidateEntry(key string, entry *pipelinesCacheEntry) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.entries[key] == entry {
+		delete(pc.entries, key)
+	}
+}
+
+// copy prevents callers from altering the cached list
+func (p *LogstashPipelines) copy() *LogstashPipelines {
+	pipelines := make([]LogstashPipelineSummary, len(p.Pipelines))
+	copy(pipelines, p.Pipelines)
+	return &LogstashPipelines{Pipelines: pipelines}
+}
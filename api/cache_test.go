@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPipelinesServer stands in for Kibana with count pipelines, listRequests counts the /pipelines calls
+func newPipelinesServer(count int, latency time.Duration, listRequests *int32) *httptest.Server {
+	list := LogstashPipelines{}
+	for i := 0; i < count; i++ {
+		list.Pipelines = append(list.Pipelines, LogstashPipelineSummary{ID: fmt.Sprintf("pipeline-%d", i), Username: "elastic"})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(latency)
+		switch {
+		case strings.HasSuffix(r.URL.Path, getAllBaseURL):
+			atomic.AddInt32(listRequests, 1)
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"pipeline":"input {}","settings":{}}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestPipelinesCacheDeduplicatesConcurrentCalls(t *testing.T) {
+	var listRequests int32
+	ts := newPipelinesServer(10, 50*time.Millisecond, &listRequests)
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pipelines, err := client.GetLogstashPipelines(context.Background())
+			assert.Nil(t, err, "expecting nil error")
+			assert.Len(t, pipelines.Pipelines, 10)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), listRequests, "expecting concurrent calls to share a single request")
+}
+
+func TestPipelinesCacheWaiterRetriesCanceledFetch(t *testing.T) {
+	var listRequests int32
+	ts := newPipelinesServer(10, 100*time.Millisecond, &listRequests)
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := client.GetLogstashPipelines(ctx)
+		assert.NotNil(t, err, "expecting the first caller to time out")
+	}()
+	// Let the first caller start the fetch
+	time.Sleep(10 * time.Millisecond)
+
+	pipelines, err := client.GetLogstashPipelines(context.Background())
+	assert.Nil(t, err, "expecting the waiting caller not to get the error of the canceled fetch")
+	assert.Len(t, pipelines.Pipelines, 10)
+	wg.Wait()
+	assert.Equal(t, int32(2), listRequests, "expecting the list to be fetched again")
+}
+
+func TestPipelinesCacheInvalidation(t *testing.T) {
+	var listRequests int32
+	ts := newPipelinesServer(1, 0, &listRequests)
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+	ctx := context.Background()
+
+	client.GetLogstashPipelines(ctx)
+	client.GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(1), listRequests, "expecting the list to be cached")
+
+	// The cache is per space but shared by the clients returned by WithSpace
+	client.WithSpace("team-a").GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(2), listRequests, "expecting the list to be cached per space")
+	client.WithSpace("team-a").GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(2), listRequests, "expecting the cache to be shared")
+
+	assert.Nil(t, client.CreateOrUpdateLogstashPipeline(ctx, NewLogstashPipeline("id", "", "input {}", nil)))
+	client.GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(3), listRequests, "expecting create/update to invalidate the cache")
+
+	assert.Nil(t, client.DeleteLogstashPipeline(ctx, "id"))
+	client.GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(4), listRequests, "expecting delete to invalidate the cache")
+
+	client.WithSpace("team-a").GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(4), listRequests, "expecting other spaces to be kept")
+}
+
+func TestPipelinesCacheExpiration(t *testing.T) {
+	var listRequests int32
+	ts := newPipelinesServer(1, 0, &listRequests)
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+	client.SetPipelinesCacheTTL(20 * time.Millisecond)
+	ctx := context.Background()
+
+	client.GetLogstashPipelines(ctx)
+	time.Sleep(30 * time.Millisecond)
+	client.GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(2), listRequests, "expecting the list to expire")
+
+	client.SetPipelinesCacheTTL(0)
+	client.GetLogstashPipelines(ctx)
+	client.GetLogstashPipelines(ctx)
+	assert.Equal(t, int32(4), listRequests, "expecting the cache to be disabled")
+}
+
+func TestPipelinesCacheDoesNotKeepErrors(t *testing.T) {
+	var listRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&listRequests, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"pipelines":[{"id":"filebeat"}]}`)
+	}))
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+
+	_, err := client.GetLogstashPipelines(context.Background())
+	assert.NotNil(t, err, "expecting error")
+	pipelines, err := client.GetLogstashPipelines(context.Background())
+	assert.Nil(t, err, "expecting the error not to be cached")
+	assert.Len(t, pipelines.Pipelines, 1)
+
+	// Callers cannot alter the cached list
+	pipelines.Pipelines[0].ID = "altered"
+	pipelines, _ = client.GetLogstashPipelines(context.Background())
+	assert.Equal(t, "filebeat", pipelines.Pipelines[0].ID)
+}
+
+// BenchmarkRefresh simulates a terraform refresh of 300 pipelines (10 in parallel, like terraform),
+// each read listing the pipelines before fetching its own.
+func BenchmarkRefresh(b *testing.B) {
+	for _, ttl := range []time.Duration{0, defaultPipelinesCacheTTL} {
+		b.Run(fmt.Sprintf("cache_ttl=%s", ttl), func(b *testing.B) {
+			var listRequests int32
+			ts := newPipelinesServer(300, time.Millisecond, &listRequests)
+			defer ts.Close()
+
+			for i := 0; i < b.N; i++ {
+				client := NewClient("elastic:changeme", ts.URL)
+				client.SetPipelinesCacheTTL(ttl)
+				refresh(b, client, 300, 10)
+			}
+			b.ReportMetric(float64(listRequests)/float64(b.N), "list-requests/refresh")
+		})
+	}
+}
+
+func refresh(b *testing.B, client *Client, count, parallelism int) {
+	ctx := context.Background()
+	ids := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				if _, err := client.GetLogstashPipelines(ctx); err != nil {
+					b.Error(err)
+				}
+				if _, err := client.GetLogstashPipeline(ctx, id); err != nil {
+					b.Error(err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		ids <- fmt.Sprintf("pipeline-%d", i)
+	}
+	close(ids)
+	wg.Wait()
+}
+
+func TestGetLogstashPipelineSummaryBypassesCache(t *testing.T) {
+	var listRequests int32
+	ts := newPipelinesServer(2, 0, &listRequests)
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+	ctx := context.Background()
+
+	client.GetLogstashPipelines(ctx)
+	summary, err := client.GetLogstashPipelineSummary(ctx, "pipeline-1")
+	assert.Nil(t, err)
+	assert.Equal(t, &LogstashPipelineSummary{ID: "pipeline-1", Username: "elastic"}, summary)
+	assert.Equal(t, int32(2), listRequests, "expecting a fresh list")
+
+	summary, err = client.GetLogstashPipelineSummary(ctx, "unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, summary)
+}
@@ -4,38 +4,43 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"unicode/utf8"
-
-	"github.com/skysoft-atm/terraform-provider-elastic/utils"
 )
 
 // Client is the high-level structure to interact with Elastic API
 type Client struct {
 	BaseURL    string
-	cloudAuth  string
+	Auth       Authenticator
 	HTTPClient *http.Client
-}
-
-type errorResponse struct {
-	StatusCode int    `json:"statusCode"`
-	Error      string `json:"error"`
-	Message    string `json:"message"`
+	// SpaceID is the Kibana space targeted by API calls, the default space is used when empty
+	SpaceID string
+	// MaxRetries is the number of times a request is replayed after a transient failure (429, 5xx, network error)
+	MaxRetries int
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff between two attempts, RetryWaitMax also caps Retry-After
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// pipelinesCache is shared with the copies returned by WithSpace
+	pipelinesCache *pipelinesCache
 }
 
 // LogstashPipelines object retrieved via the /pipelines directive
 type LogstashPipelines struct {
-	Pipelines []struct {
-		ID           string `json:"id"`
-		Description  string `json:"description,omitempty"`
-		LastModified string `json:"last_modified,omitempty"`
-		Username     string `json:"username"`
-	} `json:"pipelines"`
+	Pipelines []LogstashPipelineSummary `json:"pipelines"`
+}
+
+// LogstashPipelineSummary describes a pipeline in the /pipelines list (without its definition)
+type LogstashPipelineSummary struct {
+	ID           string `json:"id"`
+	Description  string `json:"description,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Username     string `json:"username"`
 }
 
 // LogstashPipeline object to be used with elastic API to define logstash pipelines
@@ -54,13 +59,38 @@ type LogstashConfiguration struct {
 }
 
 // Settings defines the options for the logstash pipeline
+// https://www.elastic.co/guide/en/logstash/current/logstash-settings-file.html
+// Pointers are used when the zero value is meaningful (e.g. queue.checkpoint.acks: 0 means unlimited)
+// and must then be sent
 type Settings struct {
-	PipelineBatchDelay    int    `json:"pipeline.batch.delay,omitempty"`
-	PipelineBatchSize     int    `json:"pipeline.batch.size,omitempty"`
-	PipelineWorkers       int    `json:"pipeline.workers,omitempty"`
-	QueueCheckpointWrites int    `json:"queue.checkpoint.writes,omitempty"`
-	QueueMaxBytes         string `json:"queue.max_bytes,omitempty"`
-	QueueType             string `json:"queue.type,omitempty"`
+	PipelineBatchDelay           int    `json:"pipeline.batch.delay,omitempty"`
+	PipelineBatchSize            int    `json:"pipeline.batch.size,omitempty"`
+	PipelineWorkers              int    `json:"pipeline.workers,omitempty"`
+	PipelineOrdered              string `json:"pipeline.ordered,omitempty"`
+	PipelineECSCompatibility     string `json:"pipeline.ecs_compatibility,omitempty"`
+	QueueCheckpointWrites        int    `json:"queue.checkpoint.writes,omitempty"`
+	QueueCheckpointAcks          *int   `json:"queue.checkpoint.acks,omitempty"`
+	QueueCheckpointInterval      *int   `json:"queue.checkpoint.interval,omitempty"`
+	QueueCheckpointRetry         *bool  `json:"queue.checkpoint.retry,omitempty"`
+	QueueMaxBytes                string `json:"queue.max_bytes,omitempty"`
+	QueueMaxEvents               *int   `json:"queue.max_events,omitempty"`
+	QueuePageCapacity            string `json:"queue.page_capacity,omitempty"`
+	QueueDrain                   *bool  `json:"queue.drain,omitempty"`
+	QueueType                    string `json:"queue.type,omitempty"`
+	DeadLetterQueueEnable        *bool  `json:"dead_letter_queue.enable,omitempty"`
+	DeadLetterQueueMaxBytes      string `json:"dead_letter_queue.max_bytes,omitempty"`
+	DeadLetterQueueStoragePolicy string `json:"dead_letter_queue.storage_policy,omitempty"`
+	DeadLetterQueueFlushInterval int    `json:"dead_letter_queue.flush_interval,omitempty"`
+}
+
+// Int returns a pointer to v, for optional settings
+func Int(v int) *int {
+	return &v
+}
+
+// Bool returns a pointer to v, for optional settings
+func Bool(v bool) *bool {
+	return &v
 }
 
 // NewLogstashPipeline returns a *LogstashPipeline struct
@@ -87,33 +117,81 @@ func NewLogstashPipelineSettings(batchDelay, batchSize, workers, queueCheckpoint
 	}
 }
 
-// NewClient returns a new HTTP Client
+// defaultRequestTimeout bounds every HTTP request unless changed with SetRequestTimeout
+const defaultRequestTimeout = time.Minute
+
+// NewClient returns a new HTTP Client authenticating with Basic auth (username:password)
 func NewClient(cloudAuth string, kibanaURL string) *Client {
+	return NewClientWithAuth(BasicAuth{CloudAuth: cloudAuth}, kibanaURL)
+}
+
+// NewClientWithAPIKey returns a new HTTP Client authenticating with an Elasticsearch API key
+func NewClientWithAPIKey(apiKey string, kibanaURL string) *Client {
+	return NewClientWithAuth(APIKeyAuth{APIKey: apiKey}, kibanaURL)
+}
+
+// NewClientWithAuth returns a new HTTP Client using the given authentication strategy
+func NewClientWithAuth(auth Authenticator, kibanaURL string) *Client {
 	return &Client{
-		BaseURL:   kibanaURL,
-		cloudAuth: cloudAuth,
+		BaseURL: kibanaURL,
+		Auth:    auth,
 		HTTPClient: &http.Client{
-			Timeout: time.Minute,
+			Timeout: defaultRequestTimeout,
 		},
+		MaxRetries:   DefaultMaxRetries,
+		RetryWaitMin: DefaultRetryWaitMin,
+		RetryWaitMax: DefaultRetryWaitMax,
+
+		pipelinesCache: newPipelinesCache(defaultPipelinesCacheTTL),
 	}
 }
 
 const (
 	crudBaseURL   = "/api/logstash/pipeline"
 	getAllBaseURL = "/api/logstash/pipelines"
+	spaceBaseURL  = "/s"
+	// DefaultSpaceID is the identifier of the Kibana default space
+	DefaultSpaceID = "default"
 )
 
-// GetLogstashPipelines return the current list of pipelines
+// WithSpace returns a copy of the client targeting the given Kibana space.
+// The client is returned as is if spaceID is empty.
+func (c *Client) WithSpace(spaceID string) *Client {
+	if len(spaceID) == 0 {
+		return c
+	}
+	space := *c
+	space.SpaceID = spaceID
+	return &space
+}
+
+// spaceURL returns the base URL prefixed with /s/{space} when a non default space is targeted
+func (c *Client) spaceURL() string {
+	if len(c.SpaceID) == 0 || c.SpaceID == DefaultSpaceID {
+		return c.BaseURL
+	}
+	return cleanURL(cleanURL(c.BaseURL, spaceBaseURL), url.PathEscape(c.SpaceID))
+}
+
+// GetLogstashPipelines return the current list of pipelines.
+// The list is cached for a short time and concurrent calls share the same request.
 func (c *Client) GetLogstashPipelines(ctx context.Context) (*LogstashPipelines, error) {
-	url := cleanURL(c.BaseURL, getAllBaseURL)
+	if c.pipelinesCache == nil {
+		return c.getLogstashPipelines(ctx)
+	}
+	return c.pipelinesCache.get(ctx, c.spaceURL(), func() (*LogstashPipelines, error) {
+		return c.getLogstashPipelines(ctx)
+	})
+}
+
+func (c *Client) getLogstashPipelines(ctx context.Context) (*LogstashPipelines, error) {
+	url := cleanURL(c.spaceURL(), getAllBaseURL)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req = req.WithContext(ctx)
-
 	res := LogstashPipelines{}
 	if err := c.sendRequest(req, &res); err != nil {
 		return nil, err
@@ -121,20 +199,52 @@ func (c *Client) GetLogstashPipelines(ctx context.Context) (*LogstashPipelines,
 	return &res, nil
 }
 
+// GetLogstashPipelineSummary returns the pipeline identified with the unique ID from a fresh list of pipelines
+// (the cache is bypassed to detect concurrent modifications), nil if it does not exist
+func (c *Client) GetLogstashPipelineSummary(ctx context.Context, id string) (*LogstashPipelineSummary, error) {
+	pipelines, err := c.getLogstashPipelines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pipelines.Pipelines {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetRequestTimeout bounds the duration of every HTTP request (retries excluded), zero means no timeout:
+// requests are then only bound by the deadline of their context
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.HTTPClient.Timeout = timeout
+}
+
+// SetPipelinesCacheTTL changes how long the pipelines list is cached, a zero or negative TTL disables the cache
+func (c *Client) SetPipelinesCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		c.pipelinesCache = nil
+		return
+	}
+	c.pipelinesCache = newPipelinesCache(ttl)
+}
+
+// invalidatePipelinesCache must be called after any change on pipelines
+func (c *Client) invalidatePipelinesCache() {
+	if c.pipelinesCache != nil {
+		c.pipelinesCache.invalidate(c.spaceURL())
+	}
+}
+
 // GetLogstashPipeline retrieve the pipeline identified with the unique ID
 func (c *Client) GetLogstashPipeline(ctx context.Context, id string) (*LogstashPipeline, error) {
-	url := cleanURL(cleanURL(c.BaseURL, crudBaseURL), id)
+	url := cleanURL(cleanURL(c.spaceURL(), crudBaseURL), id)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		if err.Error() == "Not Found" {
-			return &LogstashPipeline{}, nil
-		}
 		return nil, err
 	}
 
-	req = req.WithContext(ctx)
-
 	res := LogstashConfiguration{}
 	if err := c.sendRequest(req, &res); err != nil {
 		return nil, err
@@ -146,17 +256,45 @@ func (c *Client) GetLogstashPipeline(ctx context.Context, id string) (*LogstashP
 	}, nil
 }
 
+// FindLogstashPipeline returns the pipeline identified with the unique ID with its summary in the pipelines list,
+// nil if it does not exist
+func (c *Client) FindLogstashPipeline(ctx context.Context, id string) (*LogstashPipeline, *LogstashPipelineSummary, error) {
+	// API crashes if the pipeline_id is not known
+	// Let's first look if we can find it in a list
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var summary *LogstashPipelineSummary
+	for i, p := range pipes.Pipelines {
+		if id == p.ID {
+			summary = &pipes.Pipelines[i]
+			break
+		}
+	}
+	if summary == nil {
+		return nil, nil, nil
+	}
+
+	pipeline, err := c.GetLogstashPipeline(ctx, id)
+	if IsNotFound(err) {
+		// Deleted in between
+		return nil, nil, nil
+	}
+	return pipeline, summary, err
+}
+
 // DeleteLogstashPipeline deletes a specific logstash pipeline
 func (c *Client) DeleteLogstashPipeline(ctx context.Context, id string) error {
-	url := cleanURL(cleanURL(c.BaseURL, crudBaseURL), id)
+	url := cleanURL(cleanURL(c.spaceURL(), crudBaseURL), id)
 
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
 
-	req = req.WithContext(ctx)
-
+	defer c.invalidatePipelinesCache()
 	if err := c.sendRequest(req, nil); err != nil {
 		return err
 	}
@@ -171,7 +309,7 @@ func (c *Client) CreateOrUpdateLogstashPipeline(ctx context.Context, lp *Logstas
 		return err
 	}
 
-	url := cleanURL(cleanURL(c.BaseURL, crudBaseURL), lp.ID)
+	url := cleanURL(cleanURL(c.spaceURL(), crudBaseURL), lp.ID)
 
 	// marshal LogstashPipeline to JSON
 	json, err := json.Marshal(lp.Configuration)
@@ -179,13 +317,12 @@ func (c *Client) CreateOrUpdateLogstashPipeline(ctx context.Context, lp *Logstas
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(json))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(json))
 	if err != nil {
 		return err
 	}
 
-	req = req.WithContext(ctx)
-
+	defer c.invalidatePipelinesCache()
 	if err := c.sendRequest(req, nil); err != nil {
 		return err
 	}
@@ -198,28 +335,24 @@ func (c *Client) sendRequest(req *http.Request, v interface{}) error {
 	req.Header.Set("kbn-xsrf", "true")
 	req.Header.Set("Cache-Control", "no-cache")
 
-	username, password, err := utils.ParseTwoPartID(c.cloudAuth, "username", "password")
-	if err != nil {
-		return err
+	if c.Auth != nil {
+		if err := c.Auth.Authenticate(req); err != nil {
+			return err
+		}
 	}
-	req.SetBasicAuth(username, password)
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
-		var errRes errorResponse
-		if err = json.NewDecoder(res.Body).Decode(&errRes); err == nil {
-			return errors.New(errRes.Message)
-		}
-		errBody, err := ioutil.ReadAll(res.Body)
+		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+			return &APIError{StatusCode: res.StatusCode, Method: req.Method, URL: req.URL.String()}
 		}
-		return fmt.Errorf("unknown error, status code: %d, message: %s", res.StatusCode, string(errBody))
+		return newAPIError(res, body)
 	}
 
 	if v != nil {
@@ -231,6 +364,34 @@ func (c *Client) sendRequest(req *http.Request, v interface{}) error {
 	return nil
 }
 
+// do sends the request, replaying it with backoff while failures are transient
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		res, err := c.HTTPClient.Do(req)
+		if attempt >= c.MaxRetries || !shouldRetry(req, res, err) {
+			return res, err
+		}
+
+		wait := backoff(c.RetryWaitMin, c.RetryWaitMax, attempt, res)
+		if !sleep(req.Context(), wait) {
+			return res, err
+		}
+		if res != nil {
+			// Drain the body so that the connection can be reused
+			ioutil.ReadAll(res.Body)
+			res.Body.Close()
+		}
+	}
+}
+
 func (p *LogstashPipeline) String() string {
 	js, err := json.MarshalIndent(p, "", "\t")
 	if err != nil {
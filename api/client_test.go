@@ -2,10 +2,15 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/lithammer/shortuuid/v3"
 	"github.com/stretchr/testify/assert"
@@ -29,9 +34,6 @@ var (
 )
 
 func init() {
-	// Check env variables exist
-	assertPrerequisites()
-
 	c = NewClient(os.Getenv("CLOUD_AUTH"), os.Getenv("KIBANA_URL"))
 	err := json.Unmarshal(refPipeline, &pipelineRef)
 	if err != nil {
@@ -40,6 +42,7 @@ func init() {
 }
 
 func TestCreateAndGetPipeline(t *testing.T) {
+	testAccPreCheck(t)
 	ctx := context.Background()
 	pipeline := &LogstashPipeline{ID: generatePipelineID(), Configuration: &pipelineRef}
 
@@ -98,6 +101,7 @@ func TestCleanURL(t *testing.T) {
 }
 
 func TestGetAll(t *testing.T) {
+	testAccPreCheck(t)
 	pipelines, err := c.GetLogstashPipelines(context.Background())
 	assert.Nil(t, err, "expecting nil error")
 	assert.NotEmpty(t, pipelines.Pipelines, "pipelines slice should not be empty")
@@ -109,6 +113,46 @@ func TestGetAll(t *testing.T) {
 	}
 }
 
+func TestAuthorizationHeader(t *testing.T) {
+	encodedKey := base64.StdEncoding.EncodeToString([]byte("keyid:secret"))
+	tests := []struct {
+		name     string
+		client   func(url string) *Client
+		expected string
+	}{
+		{"basic", func(url string) *Client { return NewClient("elastic:changeme", url) },
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("elastic:changeme"))},
+		{"api key id:key", func(url string) *Client { return NewClientWithAPIKey("keyid:secret", url) },
+			"ApiKey " + encodedKey},
+		{"api key encoded", func(url string) *Client { return NewClientWithAPIKey(encodedKey, url) },
+			"ApiKey " + encodedKey},
+		{"bearer", func(url string) *Client { return NewClientWithAuth(BearerAuth{Token: "service-token"}, url) },
+			"Bearer service-token"},
+	}
+
+	for _, test := range tests {
+		var header string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Get("Authorization")
+			fmt.Fprint(w, `{"pipelines":[]}`)
+		}))
+
+		_, err := test.client(ts.URL).GetLogstashPipelines(context.Background())
+		ts.Close()
+
+		assert.Nil(t, err, "[ %s ] expecting nil error", test.name)
+		assert.Equal(t, test.expected, header, "[ %s ] unexpected Authorization header", test.name)
+	}
+}
+
+// testAccPreCheck skips tests requiring a running Kibana unless TF_ACC is set (see make testacc)
+func testAccPreCheck(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+	assertPrerequisites()
+}
+
 func assertPrerequisites() {
 	if len(os.Getenv("CLOUD_AUTH")) == 0 {
 		panic("CLOUD_AUTH env variable should be set for tests")
@@ -121,3 +165,108 @@ func assertPrerequisites() {
 func generatePipelineID() string {
 	return shortuuid.New()
 }
+
+func TestSpaceURL(t *testing.T) {
+	tests := []struct {
+		spaceID  string
+		expected string
+	}{
+		{"", "/api/logstash/pipelines"},
+		{DefaultSpaceID, "/api/logstash/pipelines"},
+		{"team-a", "/s/team-a/api/logstash/pipelines"},
+	}
+
+	for _, test := range tests {
+		var path string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			fmt.Fprint(w, `{"pipelines":[]}`)
+		}))
+
+		_, err := NewClient("elastic:changeme", ts.URL).WithSpace(test.spaceID).GetLogstashPipelines(context.Background())
+		ts.Close()
+
+		assert.Nil(t, err, "[ %s ] expecting nil error", test.spaceID)
+		assert.Equal(t, test.expected, path, "[ %s ] unexpected path", test.spaceID)
+	}
+}
+
+func TestSettingsJSON(t *testing.T) {
+	settings := &Settings{
+		PipelineWorkers:       2,
+		PipelineOrdered:       "auto",
+		QueueCheckpointAcks:   Int(0),
+		QueueMaxEvents:        Int(1000),
+		DeadLetterQueueEnable: Bool(false),
+	}
+	body, err := json.Marshal(settings)
+	assert.Nil(t, err, "expecting nil error")
+	// Explicit zero values of optional settings are sent, unset ones are omitted
+	assert.JSONEq(t, `{
+		"pipeline.workers": 2,
+		"pipeline.ordered": "auto",
+		"queue.checkpoint.acks": 0,
+		"queue.max_events": 1000,
+		"dead_letter_queue.enable": false
+	}`, string(body))
+
+	var decoded Settings
+	assert.Nil(t, json.Unmarshal(body, &decoded), "expecting nil error")
+	assert.Equal(t, settings, &decoded, "expecting same settings")
+}
+
+func TestRequestTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+	client.MaxRetries = 0
+	assert.Equal(t, time.Minute, client.HTTPClient.Timeout, "expecting a default request timeout")
+
+	client.SetRequestTimeout(50 * time.Millisecond)
+	start := time.Now()
+	_, err := client.WithSpace("team-a").GetLogstashPipelines(context.Background())
+	assert.NotNil(t, err, "expecting timeout error")
+	assert.True(t, time.Since(start) < time.Second, "expecting the request to be interrupted")
+}
+
+func TestContextDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+	client := NewClient("elastic:changeme", ts.URL)
+	client.SetRequestTimeout(0)
+
+	calls := map[string]func(ctx context.Context) error{
+		"list": func(ctx context.Context) error {
+			_, err := client.GetLogstashPipelines(ctx)
+			return err
+		},
+		"get": func(ctx context.Context) error {
+			_, err := client.GetLogstashPipeline(ctx, "id")
+			return err
+		},
+		"put": func(ctx context.Context) error {
+			return client.CreateOrUpdateLogstashPipeline(ctx, NewLogstashPipeline("id", "", "input {}", nil))
+		},
+		"delete": func(ctx context.Context) error {
+			return client.DeleteLogstashPipeline(ctx, "id")
+		},
+	}
+	for name, call := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := call(ctx)
+		cancel()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "%s: expecting deadline error, got %v", name, err)
+		assert.True(t, time.Since(start) < time.Second, "%s: expecting the request to be interrupted", name)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const defaultCloudPort = "443"
+
+// CloudID holds the endpoints encoded in an Elastic Cloud cloud_id (name:base64(host$es_uuid$kibana_uuid))
+// https://www.elastic.co/guide/en/cloud/current/ec-cloud-id.html
+type CloudID struct {
+	Name              string
+	Host              string
+	Port              string
+	ElasticsearchUUID string
+	KibanaUUID        string
+}
+
+// ParseCloudID decodes an Elastic Cloud cloud_id
+func ParseCloudID(cloudID string) (*CloudID, error) {
+	parts := strings.SplitN(cloudID, ":", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("invalid cloud_id (%q): expected name:base64_encoded_endpoints", cloudID)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		// Some cloud IDs are shared without padding
+		var rawErr error
+		if decoded, rawErr = base64.RawStdEncoding.DecodeString(strings.TrimRight(parts[1], "=")); rawErr != nil {
+			return nil, fmt.Errorf("invalid cloud_id (%q): unable to decode base64 part: %s", cloudID, err)
+		}
+	}
+
+	endpoints := strings.Split(string(decoded), "$")
+	if len(endpoints) < 2 || len(endpoints[0]) == 0 || len(endpoints[1]) == 0 {
+		return nil, fmt.Errorf("invalid cloud_id (%q): decoded value %q should be host$elasticsearch_uuid$kibana_uuid", cloudID, string(decoded))
+	}
+
+	id := &CloudID{
+		Name:              parts[0],
+		Host:              endpoints[0],
+		Port:              defaultCloudPort,
+		ElasticsearchUUID: endpoints[1],
+	}
+	if len(endpoints) > 2 {
+		id.KibanaUUID = endpoints[2]
+	}
+	if i := strings.LastIndex(id.Host, ":"); i >= 0 {
+		id.Host, id.Port = id.Host[:i], id.Host[i+1:]
+		if len(id.Host) == 0 || len(id.Port) == 0 {
+			return nil, fmt.Errorf("invalid cloud_id (%q): malformed host %q", cloudID, endpoints[0])
+		}
+	}
+	return id, nil
+}
+
+// ElasticsearchURL returns the URL of the Elasticsearch endpoint
+func (c *CloudID) ElasticsearchURL() string {
+	return c.url(c.ElasticsearchUUID)
+}
+
+// KibanaURL returns the URL of the Kibana endpoint, an error is returned if the cloud_id has no Kibana part
+func (c *CloudID) KibanaURL() (string, error) {
+	if len(c.KibanaUUID) == 0 {
+		return "", fmt.Errorf("cloud_id %q does not contain any Kibana endpoint", c.Name)
+	}
+	return c.url(c.KibanaUUID), nil
+}
+
+// url builds the endpoint URL, a uuid may override the port (uuid:port)
+func (c *CloudID) url(uuid string) string {
+	port := c.Port
+	if i := strings.LastIndex(uuid, ":"); i >= 0 {
+		uuid, port = uuid[:i], uuid[i+1:]
+	}
+	if port == defaultCloudPort {
+		return fmt.Sprintf("https://%s.%s", uuid, c.Host)
+	}
+	return fmt.Sprintf("https://%s.%s:%s", uuid, c.Host, port)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeCloudID(name, endpoints string) string {
+	return name + ":" + base64.StdEncoding.EncodeToString([]byte(endpoints))
+}
+
+func TestParseCloudID(t *testing.T) {
+	tests := []struct {
+		cloudID          string
+		elasticsearchURL string
+		kibanaURL        string
+	}{
+		{encodeCloudID("staging", "europe-west1.gcp.cloud.es.io$es123$kb456"),
+			"https://es123.europe-west1.gcp.cloud.es.io", "https://kb456.europe-west1.gcp.cloud.es.io"},
+		{encodeCloudID("staging", "europe-west1.gcp.cloud.es.io:9243$es123$kb456"),
+			"https://es123.europe-west1.gcp.cloud.es.io:9243", "https://kb456.europe-west1.gcp.cloud.es.io:9243"},
+		{encodeCloudID("staging", "europe-west1.gcp.cloud.es.io:443$es123:9243$kb456"),
+			"https://es123.europe-west1.gcp.cloud.es.io:9243", "https://kb456.europe-west1.gcp.cloud.es.io"},
+		// without padding
+		{"staging:" + base64.RawStdEncoding.EncodeToString([]byte("cloud.es.io$es1$kb2")),
+			"https://es1.cloud.es.io", "https://kb2.cloud.es.io"},
+	}
+
+	for _, test := range tests {
+		id, err := ParseCloudID(test.cloudID)
+		if assert.Nil(t, err, "[ %s ] expecting nil error", test.cloudID) {
+			assert.Equal(t, "staging", id.Name)
+			assert.Equal(t, test.elasticsearchURL, id.ElasticsearchURL())
+			kibanaURL, err := id.KibanaURL()
+			assert.Nil(t, err)
+			assert.Equal(t, test.kibanaURL, kibanaURL)
+		}
+	}
+}
+
+func TestParseCloudIDErrors(t *testing.T) {
+	tests := []struct {
+		cloudID  string
+		expected string
+	}{
+		{"no-separator", "expected name:base64_encoded_endpoints"},
+		{"staging:", "expected name:base64_encoded_endpoints"},
+		{"staging:!!!notbase64", "unable to decode base64 part"},
+		{encodeCloudID("staging", "cloud.es.io"), "should be host$elasticsearch_uuid$kibana_uuid"},
+		{encodeCloudID("staging", "$es1$kb2"), "should be host$elasticsearch_uuid$kibana_uuid"},
+		{encodeCloudID("staging", "cloud.es.io:$es1$kb2"), "malformed host"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseCloudID(test.cloudID)
+		if assert.Error(t, err, "[ %s ] expecting error", test.cloudID) {
+			assert.Contains(t, err.Error(), test.expected)
+		}
+	}
+
+	id, err := ParseCloudID(encodeCloudID("staging", "cloud.es.io$es1"))
+	assert.Nil(t, err)
+	_, err = id.KibanaURL()
+	assert.EqualError(t, err, `cloud_id "staging" does not contain any Kibana endpoint`)
+}
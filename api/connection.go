@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+// Environment variables holding the connection settings, shared by the provider and the elastic-pipelines command
+const (
+	EnvKibanaURL   = "KIBANA_URL"
+	EnvCloudID     = "CLOUD_ID"
+	EnvCloudAuth   = "CLOUD_AUTH"
+	EnvAPIKey      = "KIBANA_API_KEY"
+	EnvBearerToken = "KIBANA_BEARER_TOKEN"
+	EnvSpaceID     = "KIBANA_SPACE_ID"
+
+	EnvCAFile             = "KIBANA_CA_FILE"
+	EnvCAPEM              = "KIBANA_CA_PEM"
+	EnvClientCert         = "KIBANA_CLIENT_CERT"
+	EnvClientKey          = "KIBANA_CLIENT_KEY"
+	EnvInsecureSkipVerify = "KIBANA_INSECURE_SKIP_VERIFY"
+	// The retry waits are numbers of seconds
+	EnvMaxRetries   = "KIBANA_MAX_RETRIES"
+	EnvRetryWaitMin = "KIBANA_RETRY_WAIT_MIN"
+	EnvRetryWaitMax = "KIBANA_RETRY_WAIT_MAX"
+)
+
+// ConnectionNames are the names of the connection settings used in error messages
+type ConnectionNames struct {
+	KibanaURL    string
+	CloudID      string
+	CloudAuth    string
+	APIKey       string
+	BearerToken  string
+	RetryWaitMin string
+	RetryWaitMax string
+}
+
+// EnvNames names the connection settings after their environment variables
+var EnvNames = ConnectionNames{
+	KibanaURL:    EnvKibanaURL,
+	CloudID:      EnvCloudID,
+	CloudAuth:    EnvCloudAuth,
+	APIKey:       EnvAPIKey,
+	BearerToken:  EnvBearerToken,
+	RetryWaitMin: EnvRetryWaitMin,
+	RetryWaitMax: EnvRetryWaitMax,
+}
+
+// Connection holds the settings needed to reach Kibana
+type Connection struct {
+	KibanaURL   string
+	CloudID     string
+	CloudAuth   string
+	APIKey      string
+	BearerToken string
+	SpaceID     string
+	TLS         TLSConfig
+	// Retry is nil to keep the default retry settings
+	Retry *RetryConfig
+	// Names is used in error messages, EnvNames when empty
+	Names ConnectionNames
+}
+
+// ConnectionFromEnv reads the connection settings from the environment variables
+func ConnectionFromEnv(getenv func(string) string) (Connection, error) {
+	c := Connection{
+		KibanaURL:   getenv(EnvKibanaURL),
+		CloudID:     getenv(EnvCloudID),
+		CloudAuth:   getenv(EnvCloudAuth),
+		APIKey:      getenv(EnvAPIKey),
+		BearerToken: getenv(EnvBearerToken),
+		SpaceID:     getenv(EnvSpaceID),
+		TLS: TLSConfig{
+			CAFile:     getenv(EnvCAFile),
+			CAPEM:      getenv(EnvCAPEM),
+			ClientCert: getenv(EnvClientCert),
+			ClientKey:  getenv(EnvClientKey),
+		},
+	}
+
+	if v := getenv(EnvInsecureSkipVerify); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid %s %q, expected true or false", EnvInsecureSkipVerify, v)
+		}
+		c.TLS.InsecureSkipVerify = insecure
+	}
+
+	retry := DefaultRetryConfig()
+	for _, setting := range []struct {
+		env     string
+		value   *int
+		seconds *time.Duration
+	}{
+		{env: EnvMaxRetries, value: &retry.MaxRetries},
+		{env: EnvRetryWaitMin, seconds: &retry.WaitMin},
+		{env: EnvRetryWaitMax, seconds: &retry.WaitMax},
+	} {
+		v := getenv(setting.env)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return c, fmt.Errorf("invalid %s %q, expected a non negative integer", setting.env, v)
+		}
+		if setting.value != nil {
+			*setting.value = n
+		} else {
+			*setting.seconds = time.Duration(n) * time.Second
+		}
+	}
+	c.Retry = &retry
+	return c, nil
+}
+
+// URL returns KibanaURL when set, otherwise the Kibana endpoint encoded in CloudID
+func (c Connection) URL() (string, error) {
+	if c.KibanaURL != "" {
+		return c.KibanaURL, nil
+	}
+	names := c.names()
+	if c.CloudID == "" {
+		return "", fmt.Errorf("neither %s nor %s are set", names.KibanaURL, names.CloudID)
+	}
+	id, err := ParseCloudID(c.CloudID)
+	if err != nil {
+		return "", err
+	}
+	return id.KibanaURL()
+}
+
+// Authenticator returns the authentication strategy of the only credentials set
+func (c Connection) Authenticator() (Authenticator, error) {
+	names := c.names()
+	var auth Authenticator
+	var configured []string
+	if c.CloudAuth != "" {
+		configured = append(configured, names.CloudAuth)
+		// The parsing error is not returned as it holds the credentials
+		if _, _, err := utils.ParseTwoPartID(c.CloudAuth, "username", "password"); err != nil {
+			return nil, fmt.Errorf("invalid %s, expected username:password", names.CloudAuth)
+		}
+		auth = BasicAuth{CloudAuth: c.CloudAuth}
+	}
+	if c.APIKey != "" {
+		configured = append(configured, names.APIKey)
+		auth = APIKeyAuth{APIKey: c.APIKey}
+	}
+	if c.BearerToken != "" {
+		configured = append(configured, names.BearerToken)
+		auth = BearerAuth{Token: c.BearerToken}
+	}
+
+	switch len(configured) {
+	case 0:
+		return nil, fmt.Errorf("none of %s, %s or %s are set", names.CloudAuth, names.APIKey, names.BearerToken)
+	case 1:
+		return auth, nil
+	default:
+		return nil, fmt.Errorf("%s are mutually exclusive, only one of them must be set", strings.Join(configured, ", "))
+	}
+}
+
+// ValidateRetry checks the retry settings, if any
+func (c Connection) ValidateRetry() error {
+	if c.Retry == nil || c.Retry.WaitMin <= c.Retry.WaitMax {
+		return nil
+	}
+	names := c.names()
+	return fmt.Errorf("%s cannot be greater than %s, got %s and %s", names.RetryWaitMin, names.RetryWaitMax, c.Retry.WaitMin, c.Retry.WaitMax)
+}
+
+// NewClient returns a client for the connection, targeting SpaceID by default
+func (c Connection) NewClient() (*Client, error) {
+	kibanaURL, err := c.URL()
+	if err != nil {
+		return nil, err
+	}
+	auth, err := c.Authenticator()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := c.TLS.Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ValidateRetry(); err != nil {
+		return nil, err
+	}
+
+	client := NewClientWithAuth(auth, kibanaURL)
+	if tlsConfig != nil {
+		client.SetTLSConfig(tlsConfig)
+	}
+	if c.Retry != nil {
+		client.SetRetryConfig(*c.Retry)
+	}
+	client.SpaceID = c.SpaceID
+	return client, nil
+}
+
+func (c Connection) names() ConnectionNames {
+	if c.Names == (ConnectionNames{}) {
+		return EnvNames
+	}
+	return c.Names
+}
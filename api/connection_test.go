@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionFromEnv(t *testing.T) {
+	tests := []struct {
+		env           map[string]string
+		expectedURL   string
+		expectedError string
+	}{
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "elastic:changeme"}, "http://localhost:5601", ""},
+		{map[string]string{"CLOUD_ID": "staging:" + base64.StdEncoding.EncodeToString([]byte("cloud.es.io$es1$kb1")), "KIBANA_API_KEY": "key"}, "https://kb1.cloud.es.io", ""},
+		{map[string]string{"CLOUD_AUTH": "elastic:changeme"}, "", "neither KIBANA_URL nor CLOUD_ID are set"},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601"}, "", "none of CLOUD_AUTH, KIBANA_API_KEY or KIBANA_BEARER_TOKEN are set"},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "changeme"}, "", "invalid CLOUD_AUTH, expected username:password"},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_BEARER_TOKEN": "token"}, "", "CLOUD_AUTH, KIBANA_BEARER_TOKEN are mutually exclusive, only one of them must be set"},
+		{map[string]string{"KIBANA_URL": "https://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_CA_PEM": "invalid"}, "", "no valid PEM encoded certificate found in CA"},
+		{map[string]string{"KIBANA_URL": "https://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_INSECURE_SKIP_VERIFY": "yes"}, "", `invalid KIBANA_INSECURE_SKIP_VERIFY "yes", expected true or false`},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_MAX_RETRIES": "-1"}, "", `invalid KIBANA_MAX_RETRIES "-1", expected a non negative integer`},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_RETRY_WAIT_MIN": "1s"}, "", `invalid KIBANA_RETRY_WAIT_MIN "1s", expected a non negative integer`},
+		{map[string]string{"KIBANA_URL": "http://localhost:5601", "CLOUD_AUTH": "elastic:changeme", "KIBANA_RETRY_WAIT_MIN": "60"}, "", "KIBANA_RETRY_WAIT_MIN cannot be greater than KIBANA_RETRY_WAIT_MAX, got 1m0s and 30s"},
+	}
+
+	for _, test := range tests {
+		c, err := newClientFromEnv(test.env)
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, test.expectedURL, c.BaseURL)
+	}
+}
+
+func TestConnectionNames(t *testing.T) {
+	conn := Connection{
+		CloudAuth: "elastic:changeme",
+		APIKey:    "key",
+		Names:     ConnectionNames{KibanaURL: "kibana_url", CloudID: "cloud_id", CloudAuth: "cloud_auth", APIKey: "api_key", BearerToken: "bearer_token"},
+	}
+
+	_, err := conn.URL()
+	assert.EqualError(t, err, "neither kibana_url nor cloud_id are set")
+	_, err = conn.Authenticator()
+	assert.EqualError(t, err, "cloud_auth, api_key are mutually exclusive, only one of them must be set")
+}
+
+func TestConnectionSpace(t *testing.T) {
+	c, err := newClientFromEnv(map[string]string{"KIBANA_URL": "http://localhost:5601", "KIBANA_BEARER_TOKEN": "token", "KIBANA_SPACE_ID": "team-a"})
+	assert.Nil(t, err)
+	assert.Equal(t, "team-a", c.SpaceID)
+}
+
+func TestConnectionTLSAndRetry(t *testing.T) {
+	c, err := newClientFromEnv(map[string]string{"KIBANA_URL": "https://localhost:5601", "KIBANA_BEARER_TOKEN": "token"})
+	assert.Nil(t, err)
+	assert.Nil(t, c.HTTPClient.Transport, "expecting the default transport without TLS settings")
+	assert.Equal(t, DefaultMaxRetries, c.MaxRetries)
+	assert.Equal(t, DefaultRetryWaitMin, c.RetryWaitMin)
+	assert.Equal(t, DefaultRetryWaitMax, c.RetryWaitMax)
+
+	c, err = newClientFromEnv(map[string]string{
+		"KIBANA_URL":                  "https://localhost:5601",
+		"KIBANA_BEARER_TOKEN":         "token",
+		"KIBANA_INSECURE_SKIP_VERIFY": "true",
+		"KIBANA_MAX_RETRIES":          "0",
+		"KIBANA_RETRY_WAIT_MIN":       "2",
+		"KIBANA_RETRY_WAIT_MAX":       "10",
+	})
+	assert.Nil(t, err)
+	assert.True(t, c.HTTPClient.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, 0, c.MaxRetries)
+	assert.Equal(t, 2*time.Second, c.RetryWaitMin)
+	assert.Equal(t, 10*time.Second, c.RetryWaitMax)
+}
+
+func newClientFromEnv(env map[string]string) (*Client, error) {
+	conn, err := ConnectionFromEnv(func(name string) string { return env[name] })
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewClient()
+}
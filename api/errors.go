@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when Kibana answers with an unexpected status code
+type APIError struct {
+	// StatusCode is the HTTP status code returned by Kibana
+	StatusCode int
+	// Err is the Kibana "error" field (e.g. Not Found), empty if the response body is not a Kibana error
+	Err string
+	// Message is the Kibana "message" field, or the raw response body
+	Message string
+	// Method and URL identify the failed request
+	Method string
+	URL    string
+}
+
+type errorResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	status := e.Err
+	if len(status) == 0 {
+		status = http.StatusText(e.StatusCode)
+	}
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, status)
+	}
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.URL, e.StatusCode, status, e.Message)
+}
+
+// newAPIError builds an APIError from a Kibana response and its body
+func newAPIError(res *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+	if res.Request != nil {
+		apiErr.Method = res.Request.Method
+		apiErr.URL = res.Request.URL.String()
+	}
+
+	var errRes errorResponse
+	if err := json.Unmarshal(body, &errRes); err == nil && (len(errRes.Error) > 0 || len(errRes.Message) > 0) {
+		apiErr.Err = errRes.Error
+		apiErr.Message = errRes.Message
+	}
+	return apiErr
+}
+
+// IsNotFound reports whether err is an APIError with a 404 status code
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError with a 409 status code
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
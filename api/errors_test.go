@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		status     int
+		body       string
+		notFound   bool
+		conflict   bool
+		errorType  string
+		message    string
+		errMessage string
+	}{
+		{http.StatusNotFound, `{"statusCode":404,"error":"Not Found","message":"Not Found"}`, true, false, "Not Found", "Not Found",
+			"GET %s/api/logstash/pipeline/unknown: 404 Not Found: Not Found"},
+		{http.StatusConflict, `{"statusCode":409,"error":"Conflict","message":"version conflict"}`, false, true, "Conflict", "version conflict",
+			"GET %s/api/logstash/pipeline/unknown: 409 Conflict: version conflict"},
+		{http.StatusBadRequest, `not json`, false, false, "", "not json",
+			"GET %s/api/logstash/pipeline/unknown: 400 Bad Request: not json"},
+	}
+
+	for _, test := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			fmt.Fprint(w, test.body)
+		}))
+
+		_, err := NewClient("elastic:changeme", ts.URL).GetLogstashPipeline(context.Background(), "unknown")
+		ts.Close()
+
+		apiErr, ok := err.(*APIError)
+		if assert.True(t, ok, "[ %d ] expecting *APIError, got %T", test.status, err) {
+			assert.Equal(t, test.status, apiErr.StatusCode)
+			assert.Equal(t, test.errorType, apiErr.Err)
+			assert.Equal(t, test.message, apiErr.Message)
+			assert.Equal(t, http.MethodGet, apiErr.Method)
+			assert.Equal(t, fmt.Sprintf(test.errMessage, ts.URL), apiErr.Error())
+		}
+		assert.Equal(t, test.notFound, IsNotFound(err), "[ %d ] IsNotFound", test.status)
+		assert.Equal(t, test.conflict, IsConflict(err), "[ %d ] IsConflict", test.status)
+	}
+
+	assert.False(t, IsNotFound(fmt.Errorf("Not Found")), "only APIError should be considered")
+	assert.True(t, IsNotFound(fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusNotFound})), "wrapped APIError should be considered")
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default retry settings of the clients
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryWaitMin = time.Second
+	DefaultRetryWaitMax = 30 * time.Second
+)
+
+// RetryConfig gathers the options bounding the replay of requests after transient failures
+type RetryConfig struct {
+	// MaxRetries is the number of times a request is replayed
+	MaxRetries int
+	// WaitMin and WaitMax bound the exponential backoff between two attempts, WaitMax also caps Retry-After
+	WaitMin time.Duration
+	WaitMax time.Duration
+}
+
+// DefaultRetryConfig returns the retry settings of new clients
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{MaxRetries: DefaultMaxRetries, WaitMin: DefaultRetryWaitMin, WaitMax: DefaultRetryWaitMax}
+}
+
+// SetRetryConfig makes the client replay the requests failing transiently according to the given options
+func (c *Client) SetRetryConfig(config RetryConfig) {
+	c.MaxRetries = config.MaxRetries
+	c.RetryWaitMin = config.WaitMin
+	c.RetryWaitMax = config.WaitMax
+}
+
+// shouldRetry reports whether the outcome of a request is transient and the request can be replayed
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if !isIdempotent(req.Method) {
+		return false
+	}
+	if err != nil {
+		// Context cancellation or expiration is final
+		if req.Context().Err() != nil {
+			return false
+		}
+		// Certificate errors won't fix themselves
+		var unknownAuthority x509.UnknownAuthorityError
+		var invalidCertificate x509.CertificateInvalidError
+		var hostname x509.HostnameError
+		if errors.As(err, &unknownAuthority) || errors.As(err, &invalidCertificate) || errors.As(err, &hostname) {
+			return false
+		}
+		return true
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return res.StatusCode >= http.StatusInternalServerError && res.StatusCode != http.StatusNotImplemented
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the time to wait before the given retry attempt (starting at 0).
+// Retry-After is honored when sent by Kibana (up to max), otherwise an exponential backoff with jitter is used.
+func backoff(min, max time.Duration, attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if wait, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			if wait > max {
+				return max
+			}
+			return wait
+		}
+	}
+
+	wait := float64(min) * math.Pow(2, float64(attempt))
+	if wait > float64(max) || math.IsInf(wait, 0) {
+		wait = float64(max)
+	}
+	// Equal jitter: half of the wait is fixed, the other half is random
+	half := time.Duration(wait / 2)
+	if half <= 0 {
+		return time.Duration(wait)
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses a Retry-After header, either expressed in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// sleep waits for the given duration unless the context ends before.
+// It returns false without waiting if the context deadline would be exceeded.
+func sleep(ctx context.Context, wait time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return false
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
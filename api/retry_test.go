@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryTestClient(url string) *Client {
+	client := NewClient("elastic:changeme", url)
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = 10 * time.Millisecond
+	return client
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	tests := []struct {
+		status           int
+		expectedAttempts int32
+		success          bool
+	}{
+		{http.StatusServiceUnavailable, 3, true},
+		{http.StatusTooManyRequests, 3, true},
+		{http.StatusBadGateway, 3, true},
+		{http.StatusNotImplemented, 1, false},
+		{http.StatusBadRequest, 1, false},
+	}
+
+	for _, test := range tests {
+		var attempts int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				w.WriteHeader(test.status)
+				fmt.Fprintf(w, `{"statusCode":%d,"error":"Error","message":"failure"}`, test.status)
+				return
+			}
+			fmt.Fprint(w, `{"pipelines":[]}`)
+		}))
+
+		_, err := newRetryTestClient(ts.URL).GetLogstashPipelines(context.Background())
+		ts.Close()
+
+		assert.Equal(t, test.success, err == nil, "[ %d ] unexpected result: %v", test.status, err)
+		assert.Equal(t, test.expectedAttempts, attempts, "[ %d ] unexpected number of attempts", test.status)
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var attempts int32
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	err := newRetryTestClient(ts.URL).CreateOrUpdateLogstashPipeline(context.Background(), NewLogstashPipeline("id", "", "input {}", nil))
+	assert.Nil(t, err, "expecting nil error")
+	if assert.Len(t, bodies, 2) {
+		assert.Equal(t, bodies[0], bodies[1], "expecting the same body to be sent on retry")
+		assert.NotEmpty(t, bodies[1])
+	}
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := newRetryTestClient(ts.URL)
+	client.MaxRetries = 2
+	_, err := client.GetLogstashPipelines(context.Background())
+	assert.NotNil(t, err, "expecting error once retries are exhausted")
+	assert.Equal(t, int32(3), attempts, "expecting initial attempt and 2 retries")
+}
+
+func TestRetryRespectsContextDeadline(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	client := newRetryTestClient(ts.URL)
+	client.RetryWaitMax = time.Minute
+	_, err := client.GetLogstashPipelines(ctx)
+	assert.NotNil(t, err, "expecting error")
+	assert.Equal(t, int32(1), attempts, "expecting no retry as Retry-After exceeds the deadline")
+	assert.True(t, time.Since(start) < time.Second, "expecting to give up without waiting")
+}
+
+func TestBackoff(t *testing.T) {
+	min, max := 100*time.Millisecond, time.Second
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := backoff(min, max, attempt, nil)
+		assert.True(t, wait <= max, "attempt %d: backoff %s should not exceed %s", attempt, wait, max)
+		assert.True(t, wait >= min/2, "attempt %d: backoff %s should be at least %s", attempt, wait, min/2)
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "7")
+	assert.Equal(t, 7*time.Second, backoff(min, 10*time.Second, 0, res), "expecting Retry-After to be honored")
+	assert.Equal(t, max, backoff(min, max, 0, res), "expecting Retry-After to be capped by the maximum wait")
+}
+
+func TestShouldRetryNonIdempotent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/logstash/pipeline/id", nil)
+	res := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	assert.False(t, shouldRetry(req, res, nil), "POST should not be retried")
+	req = httptest.NewRequest(http.MethodPut, "/api/logstash/pipeline/id", nil)
+	assert.True(t, shouldRetry(req, res, nil), "PUT should be retried")
+}
@@ -0,0 +1,23 @@
+package api
+
+// Logstash default values of the pipeline settings
+// https://www.elastic.co/guide/en/logstash/current/logstash-settings-file.html
+const (
+	DefaultPipelineBatchDelay           = 50
+	DefaultPipelineBatchSize            = 125
+	DefaultPipelineWorkers              = 1
+	DefaultPipelineOrdered              = "auto"
+	DefaultQueueCheckpointWrites        = 1024
+	DefaultQueueCheckpointAcks          = 1024
+	DefaultQueueCheckpointInterval      = 1000
+	DefaultQueueCheckpointRetry         = true
+	DefaultQueueMaxBytes                = "1gb"
+	DefaultQueueMaxEvents               = 0
+	DefaultQueuePageCapacity            = "64mb"
+	DefaultQueueDrain                   = false
+	DefaultQueueType                    = "memory"
+	DefaultDeadLetterQueueEnable        = false
+	DefaultDeadLetterQueueMaxBytes      = "1024mb"
+	DefaultDeadLetterQueueStoragePolicy = "drop_newer"
+	DefaultDeadLetterQueueFlushInterval = 5000
+)
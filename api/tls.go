@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// TLSConfig gathers the options used to secure the connection with Kibana
+type TLSConfig struct {
+	// CAFile is the path of a PEM encoded CA bundle used to verify Kibana certificate
+	CAFile string
+	// CAPEM is a PEM encoded CA bundle used to verify Kibana certificate
+	CAPEM string
+	// ClientCert is the client certificate (PEM content or file path) used for mutual TLS
+	ClientCert string
+	// ClientKey is the client private key (PEM content or file path) used for mutual TLS
+	ClientKey string
+	// InsecureSkipVerify disables the verification of Kibana certificate
+	InsecureSkipVerify bool
+}
+
+// Build returns the *tls.Config matching the options, nil if the options are all empty
+func (t TLSConfig) Build() (*tls.Config, error) {
+	if t == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if len(t.CAFile) > 0 && len(t.CAPEM) > 0 {
+		return nil, fmt.Errorf("CA file and CA PEM are mutually exclusive")
+	}
+	caPEM := []byte(t.CAPEM)
+	if len(t.CAFile) > 0 {
+		content, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file: %s", err)
+		}
+		caPEM = content
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid PEM encoded certificate found in CA")
+		}
+		config.RootCAs = pool
+	}
+
+	if len(t.ClientCert) > 0 || len(t.ClientKey) > 0 {
+		if len(t.ClientCert) == 0 || len(t.ClientKey) == 0 {
+			return nil, fmt.Errorf("client certificate and client key must be specified together")
+		}
+		cert, err := readPEM(t.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client certificate: %s", err)
+		}
+		key, err := readPEM(t.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client key: %s", err)
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate/key pair: %s", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+
+	return config, nil
+}
+
+// SetTLSConfig makes the client use a transport configured with the given TLS configuration
+func (c *Client) SetTLSConfig(config *tls.Config) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	c.HTTPClient.Transport = transport
+}
+
+// readPEM returns value if it is PEM content, otherwise the content of the file it points to
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return ioutil.ReadFile(value)
+}
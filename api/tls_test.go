@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSConfigServerVerification(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"pipelines":[]}`)
+	}))
+	defer ts.Close()
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.Nil(t, ioutil.WriteFile(caFile, []byte(caPEM), 0600))
+
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		success bool
+	}{
+		{"no CA", TLSConfig{}, false},
+		{"CA PEM", TLSConfig{CAPEM: caPEM}, true},
+		{"CA file", TLSConfig{CAFile: caFile}, true},
+		{"insecure", TLSConfig{InsecureSkipVerify: true}, true},
+	}
+
+	for _, test := range tests {
+		config, err := test.config.Build()
+		assert.Nil(t, err, "[ %s ] expecting nil error", test.name)
+
+		client := NewClient("elastic:changeme", ts.URL)
+		client.MaxRetries = 0
+		client.SetTLSConfig(config)
+		_, err = client.GetLogstashPipelines(context.Background())
+		assert.Equal(t, test.success, err == nil, "[ %s ] unexpected result: %v", test.name, err)
+	}
+}
+
+func TestTLSConfigClientCertificate(t *testing.T) {
+	cert, key := generateCertificate(t)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"pipelines":[]}`)
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	withoutCert := NewClient("elastic:changeme", ts.URL)
+	withoutCert.MaxRetries = 0
+	withoutCert.SetTLSConfig(&tls.Config{InsecureSkipVerify: true})
+	_, err := withoutCert.GetLogstashPipelines(context.Background())
+	assert.NotNil(t, err, "expecting handshake error without client certificate")
+
+	config, err := TLSConfig{ClientCert: cert, ClientKey: key, InsecureSkipVerify: true}.Build()
+	assert.Nil(t, err, "expecting nil error")
+	withCert := NewClient("elastic:changeme", ts.URL)
+	withCert.SetTLSConfig(config)
+	_, err = withCert.GetLogstashPipelines(context.Background())
+	assert.Nil(t, err, "expecting nil error with client certificate")
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	cert, _ := generateCertificate(t)
+	_, otherKey := generateCertificate(t)
+
+	tests := []struct {
+		config   TLSConfig
+		expected string
+	}{
+		{TLSConfig{CAPEM: "not a certificate"}, "no valid PEM encoded certificate found in CA"},
+		{TLSConfig{CAFile: "/does/not/exist.pem"}, "unable to read CA file"},
+		{TLSConfig{CAFile: "ca.pem", CAPEM: cert}, "CA file and CA PEM are mutually exclusive"},
+		{TLSConfig{ClientCert: cert}, "client certificate and client key must be specified together"},
+		{TLSConfig{ClientCert: cert, ClientKey: otherKey}, "invalid client certificate/key pair"},
+		{TLSConfig{ClientCert: cert, ClientKey: "/does/not/exist.key"}, "unable to read client key"},
+	}
+
+	for _, test := range tests {
+		_, err := test.config.Build()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), test.expected)
+		}
+	}
+}
+
+// generateCertificate returns a PEM encoded self-signed certificate and its private key
+func generateCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
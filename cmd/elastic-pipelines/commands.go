@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+// command is a subcommand of the CLI, args are the arguments following its name
+// and getenv reads the environment variables
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error
+}
+
+var commands = []command{
+	{"list", "list", "List the pipelines", runList},
+	{"get", "get [-json] <id>", "Print the definition of a pipeline (or the whole pipeline as JSON)", runGet},
+	{"export", "export <dir>", "Write every pipeline to dir as <id>.conf (definition) and <id>.json (description and settings)", runExport},
+	{"apply", "apply <dir>", "Create or update the pipelines of dir (pipelines which are not in dir are kept)", runApply},
+	{"diff", "diff <dir>", "Show the changes apply would make (and the pipelines only in Kibana), exits with 1 if there are any changes", runDiff},
+	{"generate", "generate <dir>", "Write the Terraform configuration and import blocks of every pipeline to dir/pipelines.tf (definitions in dir/pipelines)", runGenerate},
+}
+
+// errDifferences is returned by diff when Kibana and the directory differ
+var errDifferences = errors.New("differences found")
+
+func runList(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("list takes no argument")
+	}
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return err
+	}
+	pipelines := pipes.Pipelines
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].ID < pipelines[j].ID })
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLAST MODIFIED\tUSERNAME\tDESCRIPTION")
+	for _, p := range pipelines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.ID, p.LastModified, p.Username, p.Description)
+	}
+	return w.Flush()
+}
+
+func runGet(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	asJSON := flags.Bool("json", false, "print the whole pipeline as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("get expects a pipeline ID")
+	}
+
+	id := flags.Arg(0)
+	pipeline, _, err := c.FindLogstashPipeline(ctx, id)
+	if err != nil {
+		return err
+	}
+	if pipeline == nil {
+		return fmt.Errorf("pipeline %q not found", id)
+	}
+	if *asJSON {
+		_, err = fmt.Fprintln(out, pipeline.String())
+		return err
+	}
+	_, err = fmt.Fprintln(out, strings.TrimSuffix(pipeline.Configuration.Pipeline, "\n"))
+	return err
+}
+
+func runExport(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("export expects a directory")
+	}
+	dir := args[0]
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return err
+	}
+	pipelines := pipes.Pipelines
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].ID < pipelines[j].ID })
+	for _, p := range pipelines {
+		pipeline, err := c.GetLogstashPipeline(ctx, p.ID)
+		if api.IsNotFound(err) {
+			// Deleted in between
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writePipeline(dir, pipeline); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "exported %s\n", p.ID)
+	}
+	return nil
+}
+
+func runApply(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("apply expects a directory")
+	}
+	pipelines, err := readLocalPipelines(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pipelines {
+		remote, _, err := c.FindLogstashPipeline(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		local := p.withRemoteMetadata(remote)
+		if remote != nil && definitionDiff(remote, local) == "" && metadataDiff(remote, local) == "" {
+			fmt.Fprintf(out, "unchanged %s\n", local.ID)
+			continue
+		}
+		if err := c.CreateOrUpdateLogstashPipeline(ctx, local); err != nil {
+			return fmt.Errorf("unable to apply pipeline %q: %w", local.ID, err)
+		}
+		if remote == nil {
+			fmt.Fprintf(out, "created %s\n", local.ID)
+		} else {
+			fmt.Fprintf(out, "updated %s\n", local.ID)
+		}
+	}
+	return nil
+}
+
+func runDiff(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("diff expects a directory")
+	}
+	dir := args[0]
+	pipelines, err := readLocalPipelines(dir)
+	if err != nil {
+		return err
+	}
+
+	differences := false
+	localIDs := make(map[string]bool)
+	for _, p := range pipelines {
+		localIDs[p.ID] = true
+		remote, _, err := c.FindLogstashPipeline(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if remote == nil {
+			differences = true
+			fmt.Fprintf(out, "--- kibana/%s (not found)\n+++ %s\n", p.ID, filepath.Join(dir, p.ID+definitionExtension))
+			fmt.Fprint(out, utils.LineDiff("", p.Configuration.Pipeline))
+			continue
+		}
+		local := p.withRemoteMetadata(remote)
+		if changes := definitionDiff(remote, local); changes != "" {
+			differences = true
+			fmt.Fprintf(out, "--- kibana/%s\n+++ %s\n%s", p.ID, filepath.Join(dir, p.ID+definitionExtension), changes)
+		}
+		if changes := metadataDiff(remote, local); changes != "" {
+			differences = true
+			fmt.Fprintf(out, "--- kibana/%s (description and settings)\n+++ %s\n%s", p.ID, filepath.Join(dir, p.ID+metadataExtension), changes)
+		}
+	}
+
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return err
+	}
+	var remoteOnly []string
+	for _, p := range pipes.Pipelines {
+		if !localIDs[p.ID] {
+			remoteOnly = append(remoteOnly, p.ID)
+		}
+	}
+	sort.Strings(remoteOnly)
+	for _, id := range remoteOnly {
+		// Not a difference since apply keeps them
+		fmt.Fprintf(out, "only in kibana: %s\n", id)
+	}
+
+	if differences {
+		return errDifferences
+	}
+	return nil
+}
+
+// readLocalPipelines loads the pipelines of dir, failing if any of them is not a valid pipeline definition
+func readLocalPipelines(dir string) ([]*localPipeline, error) {
+	pipelines, err := readPipelines(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("no %s file found in %s", definitionExtension, dir)
+	}
+
+	var invalid []string
+	for _, p := range pipelines {
+		if _, err := logstash.Parse(p.Configuration.Pipeline); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %s", p.ID+definitionExtension, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid pipeline definitions:\n%s", strings.Join(invalid, "\n"))
+	}
+	return pipelines, nil
+}
+
+// definitionDiff returns the line differences between the remote and local definitions,
+// none if they are semantically equivalent
+func definitionDiff(remote, local *api.LogstashPipeline) string {
+	if logstash.Equivalent(remote.Configuration.Pipeline, local.Configuration.Pipeline) {
+		return ""
+	}
+	return utils.LineDiff(remote.Configuration.Pipeline, local.Configuration.Pipeline)
+}
+
+// metadataDiff returns the line differences between the remote and local descriptions and settings, as stored in <id>.json.
+// local must come from withRemoteMetadata so that a missing <id>.json is not a difference.
+func metadataDiff(remote, local *api.LogstashPipeline) string {
+	return utils.LineDiff(metadataJSON(remote), metadataJSON(local))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+// A pipeline is stored in a directory as <id>.conf, its definition, and <id>.json, its description and settings
+const (
+	definitionExtension = ".conf"
+	metadataExtension   = ".json"
+)
+
+// pipelineMetadata is the content of <id>.json
+type pipelineMetadata struct {
+	Description string        `json:"description,omitempty"`
+	Settings    *api.Settings `json:"settings,omitempty"`
+}
+
+// localPipeline is a pipeline read from a directory
+type localPipeline struct {
+	*api.LogstashPipeline
+	// hasMetadata is false when <id>.json does not exist, the remote description and settings are then kept
+	hasMetadata bool
+}
+
+// withRemoteMetadata returns the pipeline to apply over remote (nil if it does not exist),
+// with the remote description and settings when <id>.json does not exist
+func (p *localPipeline) withRemoteMetadata(remote *api.LogstashPipeline) *api.LogstashPipeline {
+	if p.hasMetadata || remote == nil {
+		return p.LogstashPipeline
+	}
+	return api.NewLogstashPipeline(p.ID, remote.Configuration.Description, p.Configuration.Pipeline, remote.Configuration.Settings)
+}
+
+// writePipeline stores the pipeline in dir, replacing its previous files
+func writePipeline(dir string, p *api.LogstashPipeline) error {
+	definition := filepath.Join(dir, p.ID+definitionExtension)
+	if err := ioutil.WriteFile(definition, []byte(p.Configuration.Pipeline), 0644); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, p.ID+metadataExtension), []byte(metadataJSON(p)+"\n"), 0644)
+}
+
+// metadataJSON returns the content of <id>.json for the pipeline
+func metadataJSON(p *api.LogstashPipeline) string {
+	// Cannot fail, all the fields can be marshaled
+	content, _ := json.MarshalIndent(pipelineMetadata{
+		Description: p.Configuration.Description,
+		Settings:    p.Configuration.Settings,
+	}, "", "  ")
+	return string(content)
+}
+
+// readPipelines loads the pipelines stored in dir sorted by ID, <id>.json is optional
+func readPipelines(dir string) ([]*localPipeline, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []*localPipeline
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != definitionExtension {
+			continue
+		}
+		id := strings.TrimSuffix(file.Name(), definitionExtension)
+		definition, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var metadata pipelineMetadata
+		content, err := ioutil.ReadFile(filepath.Join(dir, id+metadataExtension))
+		hasMetadata := err == nil
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&metadata); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", id+metadataExtension, err)
+			}
+		}
+
+		pipelines = append(pipelines, &localPipeline{
+			LogstashPipeline: api.NewLogstashPipeline(id, metadata.Description, string(definition), metadata.Settings),
+			hasMetadata:      hasMetadata,
+		})
+	}
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].ID < pipelines[j].ID })
+	return pipelines, nil
+}
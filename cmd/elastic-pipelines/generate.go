@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/internal/pipelinesettings"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// generate writes <dir>/pipelines.tf, referencing the definitions stored in <dir>/pipelines/<id>.conf
+const (
+	configurationFile = "pipelines.tf"
+	definitionsDir    = "pipelines"
+	resourceType      = "elastic_logstash_pipeline"
+)
+
+func runGenerate(ctx context.Context, c *api.Client, args []string, getenv func(string) string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("generate expects a directory")
+	}
+	dir := args[0]
+	if err := os.MkdirAll(filepath.Join(dir, definitionsDir), 0755); err != nil {
+		return err
+	}
+
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return err
+	}
+	summaries := pipes.Pipelines
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	var pipelines []*api.LogstashPipeline
+	for _, p := range summaries {
+		pipeline, err := c.GetLogstashPipeline(ctx, p.ID)
+		if api.IsNotFound(err) {
+			// Deleted in between
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		definition := filepath.Join(dir, definitionsDir, p.ID+definitionExtension)
+		if err := ioutil.WriteFile(definition, []byte(pipeline.Configuration.Pipeline), 0644); err != nil {
+			return err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	// The configuration is written for a provider configured with the same environment variables
+	providerSpaceID := getenv(api.EnvSpaceID)
+	if err := ioutil.WriteFile(filepath.Join(dir, configurationFile), generateConfiguration(c.SpaceID, providerSpaceID, pipelines), 0644); err != nil {
+		return err
+	}
+	for _, p := range pipelines {
+		fmt.Fprintf(out, "generated %s\n", p.ID)
+	}
+	return nil
+}
+
+// generateConfiguration returns an elastic_logstash_pipeline resource and its import block for each pipeline
+// of spaceID, for a provider whose space_id is providerSpaceID. The import blocks require Terraform 1.5 or later.
+func generateConfiguration(spaceID, providerSpaceID string, pipelines []*api.LogstashPipeline) []byte {
+	sameSpace := normalizeSpaceID(spaceID) == normalizeSpaceID(providerSpaceID)
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	names := make(map[string]bool)
+	for i, p := range pipelines {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		name := resourceName(p.ID, names)
+
+		imp := body.AppendNewBlock("import", nil).Body()
+		imp.SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: resourceType}, hcl.TraverseAttr{Name: name}})
+		imp.SetAttributeValue("id", cty.StringVal(importID(spaceID, providerSpaceID, p.ID)))
+		body.AppendNewline()
+
+		resource := body.AppendNewBlock("resource", []string{resourceType, name}).Body()
+		resource.SetAttributeValue("pipeline_id", cty.StringVal(p.ID))
+		if !sameSpace {
+			resource.SetAttributeValue("space_id", cty.StringVal(normalizeSpaceID(spaceID)))
+		}
+		if len(p.Configuration.Description) > 0 {
+			resource.SetAttributeValue("description", cty.StringVal(p.Configuration.Description))
+		}
+		resource.SetAttributeRaw("pipeline", fileTokens(definitionsDir+"/"+p.ID+definitionExtension))
+
+		attributes := pipelinesettings.Attributes(p.Configuration.Settings)
+		if len(attributes) == 0 {
+			continue
+		}
+		resource.AppendNewline()
+		settings := resource.AppendNewBlock("settings", nil).Body()
+		for _, name := range sortedKeys(attributes) {
+			settings.SetAttributeValue(name, settingValue(attributes[name]))
+		}
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+// importID returns the import identifier of the elastic_logstash_pipeline resource: <id> when the pipeline is in
+// the space of the provider (where the provider imports bare IDs), <space>/<id> otherwise
+func importID(spaceID, providerSpaceID, pipelineID string) string {
+	if normalizeSpaceID(spaceID) == normalizeSpaceID(providerSpaceID) {
+		return pipelineID
+	}
+	return normalizeSpaceID(spaceID) + "/" + pipelineID
+}
+
+// normalizeSpaceID returns the ID of the space, the default one when empty
+func normalizeSpaceID(spaceID string) string {
+	if len(spaceID) == 0 {
+		return api.DefaultSpaceID
+	}
+	return spaceID
+}
+
+// resourceName returns a unique Terraform identifier for the pipeline, invalid characters being replaced by underscores
+func resourceName(pipelineID string, used map[string]bool) string {
+	var name []rune
+	for i, r := range pipelineID {
+		switch {
+		case r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				name = append(name, '_')
+			}
+		default:
+			r = '_'
+		}
+		name = append(name, r)
+	}
+	if len(name) == 0 {
+		name = []rune("pipeline")
+	}
+
+	unique := string(name)
+	for n := 2; used[unique]; n++ {
+		unique = string(name) + "_" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// fileTokens returns the tokens of file("${path.module}/<path>")
+func fileTokens(path string) hclwrite.Tokens {
+	// The path is a template, escape its sequences
+	literal := hclwrite.TokensForValue(cty.StringVal("/" + path))
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("file")},
+		{Type: hclsyntax.TokenOParen, Bytes: []byte("(")},
+		{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)},
+		{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")},
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("path")},
+		{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
+		{Type: hclsyntax.TokenIdent, Bytes: []byte("module")},
+		{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")},
+	}
+	// Skip the quotes of the literal
+	tokens = append(tokens, literal[1:len(literal)-1]...)
+	return append(tokens,
+		&hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)},
+		&hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")},
+	)
+}
+
+// settingValue converts a value of pipelinesettings.Attributes
+func settingValue(v interface{}) cty.Value {
+	switch v := v.(type) {
+	case int:
+		return cty.NumberIntVal(int64(v))
+	case bool:
+		return cty.BoolVal(v)
+	default:
+		return cty.StringVal(fmt.Sprint(v))
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{
+		"filebeat": {Description: "Filebeat \"events\" ${host}", Pipeline: "input { beats {} }", Settings: &api.Settings{PipelineWorkers: 2, QueueType: "persisted"}},
+		"2-stdin":  {Pipeline: "input { stdin {} }\n"},
+	})
+	defer kibana.Close()
+	dir, err := ioutil.TempDir("", "terraform")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	code, stdout, _ := kibana.run("generate", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "generated 2-stdin\ngenerated filebeat\n", stdout)
+
+	configuration, err := ioutil.ReadFile(filepath.Join(dir, "pipelines.tf"))
+	assert.Nil(t, err)
+	assert.Equal(t, `import {
+  to = elastic_logstash_pipeline._2-stdin
+  id = "2-stdin"
+}
+
+resource "elastic_logstash_pipeline" "_2-stdin" {
+  pipeline_id = "2-stdin"
+  pipeline    = file("${path.module}/pipelines/2-stdin.conf")
+}
+
+import {
+  to = elastic_logstash_pipeline.filebeat
+  id = "filebeat"
+}
+
+resource "elastic_logstash_pipeline" "filebeat" {
+  pipeline_id = "filebeat"
+  description = "Filebeat \"events\" $${host}"
+  pipeline    = file("${path.module}/pipelines/filebeat.conf")
+
+  settings {
+    queue_type = "persisted"
+    workers    = 2
+  }
+}
+`, string(configuration))
+
+	definition, err := ioutil.ReadFile(filepath.Join(dir, "pipelines", "2-stdin.conf"))
+	assert.Nil(t, err)
+	assert.Equal(t, "input { stdin {} }\n", string(definition))
+}
+
+func TestGenerateConfiguration_space(t *testing.T) {
+	pipelines := []*api.LogstashPipeline{api.NewLogstashPipeline("beats", "", "input { beats {} }", nil)}
+
+	assert.Contains(t, string(generateConfiguration("team-a", "", pipelines)), `import {
+  to = elastic_logstash_pipeline.beats
+  id = "team-a/beats"
+}
+
+resource "elastic_logstash_pipeline" "beats" {
+  pipeline_id = "beats"
+  space_id    = "team-a"
+`)
+
+	// Bare IDs are imported in the space of the provider
+	assert.Contains(t, string(generateConfiguration("team-a", "team-a", pipelines)), `import {
+  to = elastic_logstash_pipeline.beats
+  id = "beats"
+}
+
+resource "elastic_logstash_pipeline" "beats" {
+  pipeline_id = "beats"
+  pipeline    = file(`)
+	assert.Contains(t, string(generateConfiguration("", "team-a", pipelines)), `import {
+  to = elastic_logstash_pipeline.beats
+  id = "default/beats"
+}
+
+resource "elastic_logstash_pipeline" "beats" {
+  pipeline_id = "beats"
+  space_id    = "default"
+`)
+}
+
+func TestImportID(t *testing.T) {
+	assert.Equal(t, "beats", importID("", "", "beats"))
+	assert.Equal(t, "beats", importID("default", "", "beats"))
+	assert.Equal(t, "beats", importID("team-a", "team-a", "beats"))
+	assert.Equal(t, "team-a/beats", importID("team-a", "default", "beats"))
+	assert.Equal(t, "default/beats", importID("default", "team-a", "beats"))
+}
+
+func TestResourceName(t *testing.T) {
+	used := make(map[string]bool)
+	assert.Equal(t, "filebeat", resourceName("filebeat", used))
+	assert.Equal(t, "filebeat_2", resourceName("filebeat", used))
+	assert.Equal(t, "my_pipeline_v1", resourceName("my.pipeline v1", used))
+	assert.Equal(t, "_1st", resourceName("1st", used))
+	assert.Equal(t, "pipeline", resourceName("", used))
+}
@@ -0,0 +1,105 @@
+// Command elastic-pipelines manages the Logstash pipelines of Kibana centralized pipeline management
+// without Terraform, with the same environment variables as the provider (KIBANA_URL or CLOUD_ID,
+// CLOUD_AUTH, KIBANA_API_KEY or KIBANA_BEARER_TOKEN, KIBANA_SPACE_ID, and the TLS and retry settings).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+// Exit codes, as diff(1)
+const (
+	exitOK          = 0
+	exitDifferences = 1
+	exitError       = 2
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	code := run(ctx, os.Args[1:], os.Getenv, os.Stdout, os.Stderr)
+	cancel()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("elastic-pipelines", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	space := flags.String("space", "", "Kibana space of the pipelines (defaults to KIBANA_SPACE_ID, then to the default space)")
+	timeout := flags.Duration("timeout", 5*time.Minute, "Maximum duration of the command")
+	flags.Usage = func() {
+		usage(flags, stderr)
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if flags.NArg() == 0 {
+		usage(flags, stderr)
+		return exitError
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		c, err := newClient(getenv)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		c = c.WithSpace(*space)
+
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		err = cmd.run(ctx, c, flags.Args()[1:], getenv, stdout)
+		switch {
+		case errors.Is(err, errDifferences):
+			return exitDifferences
+		case err != nil:
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	fmt.Fprintf(stderr, "Error: unknown command %q\n\n", name)
+	usage(flags, stderr)
+	return exitError
+}
+
+// newClient returns a client configured, like the provider, with the environment variables
+func newClient(getenv func(string) string) (*api.Client, error) {
+	conn, err := api.ConnectionFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewClient()
+}
+
+func usage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintf(w, "Usage: elastic-pipelines [flags] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", cmd.usage, cmd.description)
+	}
+	fmt.Fprintf(w, "\nFlags:\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(w, "\nKibana is configured with the environment variables of the provider: KIBANA_URL or CLOUD_ID, and CLOUD_AUTH, KIBANA_API_KEY or KIBANA_BEARER_TOKEN.\n")
+	fmt.Fprintf(w, "TLS is configured with KIBANA_CA_FILE or KIBANA_CA_PEM, KIBANA_CLIENT_CERT and KIBANA_CLIENT_KEY, and KIBANA_INSECURE_SKIP_VERIFY.\n")
+	fmt.Fprintf(w, "Transient failures are retried KIBANA_MAX_RETRIES times (default 3), waiting between KIBANA_RETRY_WAIT_MIN and KIBANA_RETRY_WAIT_MAX seconds (default 1 and 30).\n")
+}
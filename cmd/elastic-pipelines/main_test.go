@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKibana stores the pipelines of the default space in memory
+type fakeKibana struct {
+	*httptest.Server
+	mu        sync.Mutex
+	pipelines map[string]api.LogstashConfiguration
+}
+
+func newFakeKibana(pipelines map[string]api.LogstashConfiguration) *fakeKibana {
+	k := &fakeKibana{pipelines: pipelines}
+	k.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/api/logstash/pipeline/")
+		switch {
+		case r.URL.Path == "/api/logstash/pipelines":
+			list := api.LogstashPipelines{}
+			for id, config := range k.pipelines {
+				list.Pipelines = append(list.Pipelines, api.LogstashPipelineSummary{ID: id, Description: config.Description, Username: "elastic"})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet:
+			if config, ok := k.pipelines[id]; ok {
+				json.NewEncoder(w).Encode(config)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut:
+			var config api.LogstashConfiguration
+			json.NewDecoder(r.Body).Decode(&config)
+			k.pipelines[id] = config
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return k
+}
+
+func (k *fakeKibana) env(name string) string {
+	return map[string]string{"KIBANA_URL": k.URL, "CLOUD_AUTH": "elastic:changeme"}[name]
+}
+
+func (k *fakeKibana) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, k.env, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExportDiffApply(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{
+		"filebeat": {Description: "Filebeat events", Pipeline: "input { beats {} }", Settings: &api.Settings{PipelineWorkers: 2}},
+		"stdin":    {Pipeline: "input { stdin {} }"},
+	})
+	defer kibana.Close()
+	dir, err := ioutil.TempDir("", "pipelines")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	code, stdout, _ := kibana.run("export", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "exported filebeat\nexported stdin\n", stdout)
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "filebeat.json"))
+	assert.Equal(t, "{\n  \"description\": \"Filebeat events\",\n  \"settings\": {\n    \"pipeline.workers\": 2\n  }\n}\n", string(content))
+
+	code, stdout, _ = kibana.run("diff", dir)
+	assert.Equal(t, exitOK, code, "expecting no difference after export")
+	assert.Equal(t, "", stdout)
+
+	// Formatting changes are not differences
+	ioutil.WriteFile(filepath.Join(dir, "stdin.conf"), []byte("input {\n  stdin {}\n}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "filebeat.conf"), []byte("input { beats { port => 5044 } }"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "metricbeat.conf"), []byte("input { beats {} }"), 0644)
+	os.Remove(filepath.Join(dir, "stdin.json"))
+	kibana.pipelines["manual"] = api.LogstashConfiguration{Pipeline: "input { stdin {} }"}
+
+	code, stdout, _ = kibana.run("diff", dir)
+	assert.Equal(t, exitDifferences, code)
+	assert.Contains(t, stdout, "--- kibana/filebeat\n+++ "+filepath.Join(dir, "filebeat.conf")+"\n- input { beats {} }\n+ input { beats { port => 5044 } }\n")
+	assert.Contains(t, stdout, "--- kibana/metricbeat (not found)\n+++ "+filepath.Join(dir, "metricbeat.conf")+"\n+ input { beats {} }\n")
+	assert.Contains(t, stdout, "only in kibana: manual\n")
+	assert.NotContains(t, stdout, "kibana/stdin")
+
+	code, stdout, _ = kibana.run("apply", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "updated filebeat\ncreated metricbeat\nunchanged stdin\n", stdout)
+	assert.Equal(t, "input { beats { port => 5044 } }", kibana.pipelines["filebeat"].Pipeline)
+	assert.Equal(t, 2, kibana.pipelines["filebeat"].Settings.PipelineWorkers)
+	assert.Contains(t, kibana.pipelines, "manual", "expecting apply to keep the other pipelines")
+
+	code, _, _ = kibana.run("diff", dir)
+	assert.Equal(t, exitOK, code, "expecting no difference after apply")
+}
+
+func TestApplyInvalidDefinitions(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{})
+	defer kibana.Close()
+	dir, err := ioutil.TempDir("", "pipelines")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "valid.conf"), []byte("input { stdin {} }"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "invalid.conf"), []byte("input { stdin {}"), 0644)
+	code, _, stderr := kibana.run("apply", dir)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "invalid pipeline definitions:\ninvalid.conf: ")
+	assert.Empty(t, kibana.pipelines, "expecting nothing to be applied")
+
+	os.Remove(filepath.Join(dir, "invalid.conf"))
+	ioutil.WriteFile(filepath.Join(dir, "valid.json"), []byte(`{"setting": {}}`), 0644)
+	code, _, stderr = kibana.run("apply", dir)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, `invalid valid.json: json: unknown field "setting"`)
+}
+
+func TestApplyWithoutMetadata(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{
+		"filebeat": {Description: "Filebeat events", Pipeline: "input { beats {} }", Settings: &api.Settings{PipelineWorkers: 2}},
+	})
+	defer kibana.Close()
+	dir, err := ioutil.TempDir("", "pipelines")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Without filebeat.json, the description and settings in Kibana are kept
+	ioutil.WriteFile(filepath.Join(dir, "filebeat.conf"), []byte("input {\n  beats {}\n}\n"), 0644)
+	code, stdout, _ := kibana.run("diff", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "", stdout)
+	code, stdout, _ = kibana.run("apply", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "unchanged filebeat\n", stdout)
+
+	ioutil.WriteFile(filepath.Join(dir, "filebeat.conf"), []byte("input { beats { port => 5044 } }"), 0644)
+	code, stdout, _ = kibana.run("diff", dir)
+	assert.Equal(t, exitDifferences, code)
+	assert.NotContains(t, stdout, "description and settings")
+	code, stdout, _ = kibana.run("apply", dir)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "updated filebeat\n", stdout)
+	assert.Equal(t, "input { beats { port => 5044 } }", kibana.pipelines["filebeat"].Pipeline)
+	assert.Equal(t, "Filebeat events", kibana.pipelines["filebeat"].Description)
+	assert.Equal(t, &api.Settings{PipelineWorkers: 2}, kibana.pipelines["filebeat"].Settings)
+}
+
+func TestListAndGet(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{
+		"stdin":    {Description: "Debug", Pipeline: "input { stdin {} }\n"},
+		"filebeat": {Pipeline: "input { beats {} }"},
+	})
+	defer kibana.Close()
+
+	code, stdout, _ := kibana.run("list")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "ID        LAST MODIFIED  USERNAME  DESCRIPTION\nfilebeat                 elastic   \nstdin                    elastic   Debug\n", stdout)
+
+	code, stdout, _ = kibana.run("get", "stdin")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "input { stdin {} }\n", stdout)
+
+	code, stdout, _ = kibana.run("get", "-json", "stdin")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"description": "Debug"`)
+
+	code, _, stderr := kibana.run("get", "unknown")
+	assert.Equal(t, exitError, code)
+	assert.Equal(t, "Error: pipeline \"unknown\" not found\n", stderr)
+}
+
+func TestUsage(t *testing.T) {
+	kibana := newFakeKibana(nil)
+	defer kibana.Close()
+
+	code, _, stderr := kibana.run()
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "Usage: elastic-pipelines")
+
+	code, _, stderr = kibana.run("sync")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "Error: unknown command \"sync\"")
+}
+
+func TestConnectionSettings(t *testing.T) {
+	kibana := newFakeKibana(map[string]api.LogstashConfiguration{})
+	defer kibana.Close()
+
+	var stdout, stderr bytes.Buffer
+	getenv := func(name string) string {
+		if name == "KIBANA_RETRY_WAIT_MIN" {
+			return "60"
+		}
+		return kibana.env(name)
+	}
+	code := run(context.Background(), []string{"list"}, getenv, &stdout, &stderr)
+	assert.Equal(t, exitError, code)
+	assert.Equal(t, "Error: KIBANA_RETRY_WAIT_MIN cannot be greater than KIBANA_RETRY_WAIT_MAX, got 1m0s and 30s\n", stderr.String())
+}
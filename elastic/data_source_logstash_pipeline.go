@@ -2,6 +2,7 @@ package elastic
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -17,6 +18,12 @@ func dataSourceLogstashPipeline() *schema.Resource {
 				Required:    true,
 				Description: `Pipeline name, must be unique.`,
 			},
+			"space_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: `Kibana space of the pipeline, defaults to the provider space_id.`,
+			},
 			"pipeline": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -33,50 +40,18 @@ func dataSourceLogstashPipeline() *schema.Resource {
 				Computed:    true,
 				Description: `Token owner used for the pipeline creation.`,
 			},
+			"last_modified": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Date of the last modification.`,
+			},
+			"pipeline_inputs_addresses": pipelineInputsAddressesSchema(),
+			"pipeline_output_addresses": pipelineOutputAddressesSchema(),
 			"settings": {
 				Type:     schema.TypeList,
 				Computed: true,
 				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"batch_delay": {
-							Type:     schema.TypeInt,
-							Computed: true,
-							Description: `This setting adjusts the latency of the Logstash pipeline. 
-							Pipeline batch delay is the maximum amount of time in milliseconds that 
-							Logstash waits for new messages after receiving an event in the current 
-							pipeline worker thread.`,
-						},
-						"batch_size": {
-							Type:     schema.TypeInt,
-							Computed: true,
-							Description: `This setting defines the maximum number of events an 
-							individual worker thread collects before attempting to execute filters 
-							and outputs. Larger batch sizes are generally more efficient, but 
-							increase memory overhead.`,
-						},
-						"workers": {
-							Type:     schema.TypeInt,
-							Computed: true,
-							Description: `This setting determines how many threads to run for filter
-							and output processing.`,
-						},
-						"queue_checkpoint_writes": {
-							Type:     schema.TypeInt,
-							Computed: true,
-							Description: `This setting specifies the maximum number of events that
-							may be written to disk before forcing a checkpoint. `,
-						},
-						"queue_max_bytes": {
-							Type:        schema.TypeString,
-							Computed:    true,
-							Description: `The total capacity of the queue in number of bytes.`,
-						},
-						"queue_type": {
-							Type:        schema.TypeString,
-							Computed:    true,
-							Description: `Persistent mode for queues (persisted or memory).`,
-						},
-					},
+					Schema: dataSourceLogstashPipelineSettingsSchema(),
 				},
 			},
 		},
@@ -84,65 +59,53 @@ func dataSourceLogstashPipeline() *schema.Resource {
 }
 
 func dataSourceLogstashPipelineRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*api.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	id := d.Get("pipeline_id").(string)
+	spaceID := pipelineSpace(d, c)
+	c = c.WithSpace(spaceID)
 
-	// API crashes if the pipeline_id is not known
-	// Let's first look if we can find it in a list
-	pipes, err := c.GetLogstashPipelines(ctx)
+	pipeline, summary, err := c.FindLogstashPipeline(ctx, id)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-
-	found := false
-	for _, p := range pipes.Pipelines {
-		if id == p.ID {
-			found = true
-			break
-		}
+	if pipeline == nil {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Logstash pipeline not found",
+			Detail:   fmt.Sprintf("No pipeline %q exists in Kibana space %q", id, spaceID),
+		})
 	}
 
-	if found {
-		pipeline, err := c.GetLogstashPipeline(ctx, id)
-		if err != nil {
+	pl := flattenLogstashPipelineData(pipeline, summary)
+	for key, value := range pl {
+		if err := d.Set(key, value); err != nil {
 			return diag.FromErr(err)
 		}
-
-		pl := flattenLogstashPipelineData(pipeline)
-		for key, value := range pl {
-			if err := d.Set(key, value); err != nil {
-				diag.FromErr(err)
-			}
-		}
 	}
-	d.SetId(id)
+	if err := d.Set("space_id", spaceID); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(buildPipelineID(spaceID, id))
 
 	return diags
 }
 
-func flattenLogstashPipelineData(pipeline *api.LogstashPipeline) map[string]interface{} {
+func flattenLogstashPipelineData(pipeline *api.LogstashPipeline, summary *api.LogstashPipelineSummary) map[string]interface{} {
 	lp := make(map[string]interface{})
+	if summary != nil {
+		lp["last_modified"] = summary.LastModified
+	}
 	if pipeline != nil {
 		lp["pipeline_id"] = pipeline.ID
 		lp["description"] = pipeline.Configuration.Description
 		lp["username"] = pipeline.Configuration.Username
 		lp["pipeline"] = pipeline.Configuration.Pipeline
 		lp["settings"] = flattenSettings(pipeline.Configuration.Settings)
+		lp["pipeline_inputs_addresses"], lp["pipeline_output_addresses"] = pipelineAddresses(pipeline.Configuration.Pipeline)
 	}
 	return lp
 }
-
-func flattenSettings(settings *api.Settings) []interface{} {
-	s := make(map[string]interface{})
-	s["workers"] = settings.PipelineWorkers
-	s["batch_size"] = settings.PipelineBatchSize
-	s["batch_delay"] = settings.PipelineBatchDelay
-	s["queue_checkpoint_writes"] = settings.QueueCheckpointWrites
-	s["queue_max_bytes"] = settings.QueueMaxBytes
-	s["queue_type"] = settings.QueueType
-	return []interface{}{s}
-}
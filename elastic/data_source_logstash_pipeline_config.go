@@ -0,0 +1,443 @@
+package elastic
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+)
+
+var logstashSections = []string{"input", "filter", "output"}
+
+func dataSourceLogstashPipelineConfig() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceLogstashPipelineConfigRead,
+		Schema: map[string]*schema.Schema{
+			"input": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: logstashSections,
+				Description:  `Input plugins and conditionals, in order.`,
+				Elem:         logstashSectionSchema(),
+			},
+			"filter": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: logstashSections,
+				Description:  `Filter plugins and conditionals, in order.`,
+				Elem:         logstashSectionSchema(),
+			},
+			"output": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				AtLeastOneOf: logstashSections,
+				Description:  `Output plugins and conditionals, in order.`,
+				Elem:         logstashSectionSchema(),
+			},
+			"pipeline": {
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: `Rendered pipeline definition, to be used as the pipeline of an
+				elastic_logstash_pipeline resource.`,
+			},
+		},
+	}
+}
+
+// maxLogstashConditionalDepth is the number of conditional blocks which can be nested,
+// Terraform schemas cannot be recursive
+const maxLogstashConditionalDepth = 3
+
+// logstashSectionSchema returns the schema of the blocks of a section: a plugin, or a conditional grouping blocks
+func logstashSectionSchema() *schema.Resource {
+	return logstashNodeSchema(maxLogstashConditionalDepth)
+}
+
+// logstashNodeSchema returns the schema of a plugin or conditional block, conditionals can be nested depth times
+func logstashNodeSchema(depth int) *schema.Resource {
+	s := logstashPluginSchema(true)
+	s.Schema["plugin"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: `Plugin name (e.g. beats, grok, elasticsearch), required unless conditional is set.`,
+	}
+	if depth == 0 {
+		return s
+	}
+
+	s.Schema["conditional"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Description: fmt.Sprintf(`if block grouping several plugins or conditionals, with optional else if and
+			else blocks, instead of a plugin (up to %d nested conditional blocks).`, maxLogstashConditionalDepth),
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"if": logstashBranchConditionSchema(),
+				"then": {
+					Type:        schema.TypeList,
+					Required:    true,
+					Description: `Plugins or conditionals applied when the condition is true, in order.`,
+					Elem:        logstashNodeSchema(depth - 1),
+				},
+				"else_if": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: `else if blocks, in order.`,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"if": logstashBranchConditionSchema(),
+							"then": {
+								Type:        schema.TypeList,
+								Required:    true,
+								Description: `Plugins or conditionals applied when the condition is true, in order.`,
+								Elem:        logstashNodeSchema(depth - 1),
+							},
+						},
+					},
+				},
+				"else": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: `Plugins or conditionals applied when no condition is true, in order.`,
+					Elem:        logstashNodeSchema(depth - 1),
+				},
+			},
+		},
+	}
+	return s
+}
+
+func logstashBranchConditionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Required:     true,
+		ValidateFunc: validateLogstashCondition,
+		Description:  `Condition (e.g. [type] == "syslog"), without the if keyword and braces.`,
+	}
+}
+
+// logstashPluginSchema returns the schema of a plugin block, section plugins can be conditional and have a codec
+func logstashPluginSchema(section bool) *schema.Resource {
+	s := map[string]*schema.Schema{
+		"plugin": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: `Plugin name (e.g. beats, grok, elasticsearch).`,
+		},
+		"settings": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Description: `Plugin settings, values are rendered as strings
+				(Logstash converts them to numbers or booleans when needed), sorted by name.`,
+		},
+		"raw_settings": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Description: `Plugin settings rendered as is, values must be Logstash values
+				(e.g. [ "message", "%%{GREEDYDATA:message}" ] or { "field" => "value" }), sorted by name.`,
+		},
+		"setting": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Description: `Plugin settings rendered in the declared order, before the settings and
+				raw_settings ones (maps lose the order, which matters for some plugins).`,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: `Setting name.`,
+					},
+					"value": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: `Setting value, rendered as a string unless raw is true.`,
+					},
+					"raw": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: `Render the value as is, it must then be a Logstash value.`,
+					},
+				},
+			},
+		},
+	}
+	if section {
+		s["if"] = &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateLogstashCondition,
+			Description:  `Condition of the plugin (e.g. [type] == "syslog"), without the if keyword and braces.`,
+		}
+		s["codec"] = &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: `Codec of the plugin.`,
+			Elem:        logstashPluginSchema(false),
+		}
+	}
+	return &schema.Resource{Schema: s}
+}
+
+func dataSourceLogstashPipelineConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	config := &logstash.Config{}
+	for _, sectionType := range logstashSections {
+		plugins := d.Get(sectionType).([]interface{})
+		if len(plugins) == 0 {
+			continue
+		}
+		section := &logstash.Section{Type: sectionType}
+		for i, v := range plugins {
+			path := cty.GetAttrPath(sectionType).IndexInt(i)
+			// Empty blocks are nil
+			data, _ := v.(map[string]interface{})
+			node, err := expandLogstashSectionNode(data)
+			if err != nil {
+				return append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       "Invalid plugin",
+					Detail:        err.Error(),
+					AttributePath: path,
+				})
+			}
+			section.Body = append(section.Body, node)
+		}
+		config.Sections = append(config.Sections, section)
+	}
+
+	pipeline := logstash.Format(config)
+	// Safety net, names or values which cannot be represented would otherwise only fail in Logstash
+	if _, err := logstash.Parse(pipeline); err != nil {
+		return diag.Errorf("Unable to render a valid pipeline definition: %s", err)
+	}
+
+	if err := d.Set("pipeline", pipeline); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(pipeline))))
+
+	return diags
+}
+
+// expandLogstashSectionNode returns the conditional of the block, or its plugin
+func expandLogstashSectionNode(data map[string]interface{}) (logstash.Node, error) {
+	conditionals, _ := data["conditional"].([]interface{})
+	plugin, _ := data["plugin"].(string)
+	if len(conditionals) == 0 || conditionals[0] == nil {
+		if len(plugin) == 0 {
+			return nil, fmt.Errorf("either plugin or conditional must be set")
+		}
+		return expandLogstashPlugin(data)
+	}
+
+	for _, name := range []string{"plugin", "if", "settings", "raw_settings", "setting", "codec"} {
+		if v, ok := data[name]; ok && !isEmptyValue(v) {
+			return nil, fmt.Errorf("%s cannot be set along with conditional", name)
+		}
+	}
+	conditional := conditionals[0].(map[string]interface{})
+	branch := &logstash.Branch{}
+	c, err := expandLogstashCase(conditional)
+	if err != nil {
+		return nil, err
+	}
+	branch.Cases = append(branch.Cases, c)
+	for i, v := range conditional["else_if"].([]interface{}) {
+		if v == nil {
+			continue
+		}
+		c, err := expandLogstashCase(v.(map[string]interface{}))
+		if err != nil {
+			return nil, fmt.Errorf("else_if %d: %s", i, err)
+		}
+		branch.Cases = append(branch.Cases, c)
+	}
+	if elses, _ := conditional["else"].([]interface{}); len(elses) > 0 {
+		body, err := expandLogstashNodes(elses)
+		if err != nil {
+			return nil, fmt.Errorf("else: %s", err)
+		}
+		branch.Else = body
+	}
+	return branch, nil
+}
+
+// expandLogstashCase returns the if or else if block of a conditional
+func expandLogstashCase(data map[string]interface{}) (*logstash.Case, error) {
+	condition := data["if"].(string)
+	c, err := logstash.ParseCondition(condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %s", condition, err)
+	}
+	body, err := expandLogstashNodes(data["then"].([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	return &logstash.Case{Condition: c, Body: body}, nil
+}
+
+// expandLogstashNodes returns the plugins and conditionals of a branch
+func expandLogstashNodes(blocks []interface{}) ([]logstash.Node, error) {
+	var body []logstash.Node
+	for _, v := range blocks {
+		// Empty blocks are nil
+		data, _ := v.(map[string]interface{})
+		node, err := expandLogstashSectionNode(data)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, node)
+	}
+	if len(body) == 0 {
+		return nil, fmt.Errorf("at least one plugin or conditional is required")
+	}
+	return body, nil
+}
+
+// isEmptyValue reports whether the attribute of a block is not set
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return v == nil
+}
+
+// expandLogstashPlugin returns the plugin, wrapped in an if block when it has a condition
+func expandLogstashPlugin(data map[string]interface{}) (logstash.Node, error) {
+	plugin, err := expandLogstashPluginAttributes(data)
+	if err != nil {
+		return nil, err
+	}
+	if codecs, ok := data["codec"].([]interface{}); ok && len(codecs) > 0 && codecs[0] != nil {
+		codec, err := expandLogstashPluginAttributes(codecs[0].(map[string]interface{}))
+		if err != nil {
+			return nil, fmt.Errorf("codec: %s", err)
+		}
+		plugin.Attributes = append(plugin.Attributes, &logstash.Attribute{Name: "codec", Value: codec})
+	}
+
+	condition, _ := data["if"].(string)
+	if len(condition) == 0 {
+		return plugin, nil
+	}
+	c, err := logstash.ParseCondition(condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %s", condition, err)
+	}
+	return &logstash.Branch{Cases: []*logstash.Case{{Condition: c, Body: []logstash.Node{plugin}}}}, nil
+}
+
+// expandLogstashPluginAttributes returns the plugin with its setting blocks in order,
+// followed by its settings and raw_settings sorted by name
+func expandLogstashPluginAttributes(data map[string]interface{}) (*logstash.Plugin, error) {
+	plugin := &logstash.Plugin{Name: data["plugin"].(string)}
+	defined := make(map[string]bool)
+	add := func(name, value string, raw bool) error {
+		if defined[name] {
+			return fmt.Errorf("setting %q is defined more than once", name)
+		}
+		defined[name] = true
+		v, err := expandLogstashSettingValue(name, value, raw)
+		if err != nil {
+			return err
+		}
+		plugin.Attributes = append(plugin.Attributes, &logstash.Attribute{Name: name, Value: v})
+		return nil
+	}
+
+	blocks, _ := data["setting"].([]interface{})
+	for _, v := range blocks {
+		// Empty blocks are nil
+		setting, _ := v.(map[string]interface{})
+		name, _ := setting["name"].(string)
+		value, _ := setting["value"].(string)
+		raw, _ := setting["raw"].(bool)
+		if err := add(name, value, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	settings, _ := data["settings"].(map[string]interface{})
+	rawSettings, _ := data["raw_settings"].(map[string]interface{})
+	for name := range settings {
+		if _, ok := rawSettings[name]; ok {
+			return nil, fmt.Errorf("setting %q is defined in both settings and raw_settings", name)
+		}
+	}
+	names := make([]string, 0, len(settings)+len(rawSettings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	for name := range rawSettings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, raw := settings[name], false
+		if value == nil {
+			value, raw = rawSettings[name], true
+		}
+		if err := add(name, value.(string), raw); err != nil {
+			return nil, err
+		}
+	}
+	return plugin, nil
+}
+
+// expandLogstashSettingValue returns the value of a plugin setting, a string unless raw
+func expandLogstashSettingValue(name, value string, raw bool) (logstash.Value, error) {
+	if !raw {
+		if hasUnescapedQuotes(value) {
+			return nil, fmt.Errorf("setting %q cannot contain both unescaped single and double quotes", name)
+		}
+		return &logstash.String{Value: value, Quote: '"'}, nil
+	}
+	v, err := logstash.ParseValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw setting %q: %s", name, err)
+	}
+	return v, nil
+}
+
+// hasUnescapedQuotes reports whether s can be neither single nor double quoted
+func hasUnescapedQuotes(s string) bool {
+	previous := rune(0)
+	single, double := false, false
+	for _, r := range s {
+		if previous != '\\' {
+			single = single || r == '\''
+			double = double || r == '"'
+		}
+		previous = r
+	}
+	return single && double
+}
+
+func validateLogstashCondition(v interface{}, k string) (ws []string, errors []error) {
+	condition := v.(string)
+	if len(condition) == 0 {
+		return
+	}
+	if _, err := logstash.ParseCondition(condition); err != nil {
+		errors = append(errors, fmt.Errorf("%q: invalid condition %q: %s", k, condition, err))
+	}
+	return
+}
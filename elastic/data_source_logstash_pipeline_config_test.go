@@ -0,0 +1,313 @@
+package elastic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+)
+
+func TestDataSourceLogstashPipelineConfigRead(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineConfig().Schema, map[string]interface{}{
+		"input": []interface{}{
+			map[string]interface{}{
+				"plugin":   "beats",
+				"settings": map[string]interface{}{"port": "5044"},
+			},
+		},
+		"filter": []interface{}{
+			map[string]interface{}{
+				"plugin": "grok",
+				"if":     `[kubernetes][labels][name] == "ems"`,
+				"settings": map[string]interface{}{
+					"target": "[host][name]",
+				},
+				"raw_settings": map[string]interface{}{
+					"match":     `[ "message", "%{TIMESTAMP_ISO8601:logdate} %{GREEDYDATA:message}" ]`,
+					"overwrite": `["message"]`,
+				},
+			},
+			map[string]interface{}{
+				"plugin": "drop",
+			},
+		},
+		"output": []interface{}{
+			map[string]interface{}{
+				"plugin":   "stdout",
+				"settings": map[string]interface{}{"message": `say "hi"`},
+				"codec": []interface{}{
+					map[string]interface{}{
+						"plugin":   "json",
+						"settings": map[string]interface{}{"charset": "UTF-8"},
+					},
+				},
+			},
+		},
+	})
+
+	diags := dataSourceLogstashPipelineConfigRead(context.Background(), d, nil)
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+
+	expected := `input {
+  beats {
+    port => "5044"
+  }
+}
+filter {
+  if [kubernetes][labels][name] == "ems" {
+    grok {
+      match => ["message", "%{TIMESTAMP_ISO8601:logdate} %{GREEDYDATA:message}"]
+      overwrite => ["message"]
+      target => "[host][name]"
+    }
+  }
+  drop {}
+}
+output {
+  stdout {
+    message => 'say "hi"'
+    codec => json {
+      charset => "UTF-8"
+    }
+  }
+}
+`
+	pipeline := d.Get("pipeline").(string)
+	if pipeline != expected {
+		t.Fatalf("expected pipeline:\n%s\ngot:\n%s", expected, pipeline)
+	}
+	if _, err := logstash.Parse(pipeline); err != nil {
+		t.Fatalf("expected the rendered pipeline to be valid, got %v", err)
+	}
+	if d.Id() == "" {
+		t.Fatalf("expected ID to be set")
+	}
+}
+
+func TestDataSourceLogstashPipelineConfigRead_conditional(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineConfig().Schema, map[string]interface{}{
+		"filter": []interface{}{
+			map[string]interface{}{
+				"conditional": []interface{}{
+					map[string]interface{}{
+						"if": `[type] == "syslog"`,
+						"then": []interface{}{
+							map[string]interface{}{
+								"plugin":       "grok",
+								"raw_settings": map[string]interface{}{"match": `{ "message" => "%{SYSLOGLINE}" }`},
+							},
+							map[string]interface{}{
+								"plugin":   "date",
+								"settings": map[string]interface{}{"target": "@timestamp"},
+							},
+						},
+						"else_if": []interface{}{
+							map[string]interface{}{
+								"if": `[type] == "nginx"`,
+								"then": []interface{}{
+									map[string]interface{}{"plugin": "geoip", "if": `[clientip]`},
+								},
+							},
+						},
+						"else": []interface{}{
+							map[string]interface{}{"plugin": "drop"},
+						},
+					},
+				},
+			},
+			map[string]interface{}{
+				"plugin": "mutate",
+			},
+		},
+	})
+
+	diags := dataSourceLogstashPipelineConfigRead(context.Background(), d, nil)
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+
+	handWritten := `filter {
+  if [type] == "syslog" {
+    grok { match => { "message" => "%{SYSLOGLINE}" } }
+    date { target => "@timestamp" }
+  } else if [type] == "nginx" {
+    if [clientip] {
+      geoip {}
+    }
+  } else {
+    drop {}
+  }
+  mutate {}
+}`
+	pipeline := d.Get("pipeline").(string)
+	if _, err := logstash.Parse(pipeline); err != nil {
+		t.Fatalf("expected the rendered pipeline to be valid, got %v", err)
+	}
+	if !logstash.Equivalent(pipeline, handWritten) {
+		t.Fatalf("expected pipeline equivalent to:\n%s\ngot:\n%s", handWritten, pipeline)
+	}
+}
+
+func TestDataSourceLogstashPipelineConfigRead_nested(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineConfig().Schema, map[string]interface{}{
+		"filter": []interface{}{
+			map[string]interface{}{
+				"conditional": []interface{}{
+					map[string]interface{}{
+						"if": `[type] == "syslog"`,
+						"then": []interface{}{
+							map[string]interface{}{
+								"conditional": []interface{}{
+									map[string]interface{}{
+										"if": `[level] == "debug"`,
+										"then": []interface{}{
+											map[string]interface{}{"plugin": "drop"},
+										},
+										"else": []interface{}{
+											map[string]interface{}{
+												"plugin": "mutate",
+												"setting": []interface{}{
+													map[string]interface{}{"name": "rename", "value": `{ "a" => "b" }`, "raw": true},
+													map[string]interface{}{"name": "copy", "value": `{ "b" => "c" }`, "raw": true},
+													map[string]interface{}{"name": "add_tag", "value": "syslog"},
+												},
+												"settings": map[string]interface{}{"id": "syslog"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	diags := dataSourceLogstashPipelineConfigRead(context.Background(), d, nil)
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+
+	handWritten := `filter {
+  if [type] == "syslog" {
+    if [level] == "debug" {
+      drop {}
+    } else {
+      mutate { rename => { "a" => "b" } copy => { "b" => "c" } add_tag => "syslog" id => "syslog" }
+    }
+  }
+}`
+	pipeline := d.Get("pipeline").(string)
+	if !logstash.Equivalent(pipeline, handWritten) {
+		t.Fatalf("expected pipeline equivalent to:\n%s\ngot:\n%s", handWritten, pipeline)
+	}
+	// The setting blocks keep their order
+	if !strings.Contains(pipeline, "rename => {") || strings.Index(pipeline, "rename") > strings.Index(pipeline, "copy") {
+		t.Fatalf("expected rename before copy, got:\n%s", pipeline)
+	}
+}
+
+func TestLogstashSectionSchema_depth(t *testing.T) {
+	s := logstashSectionSchema()
+	for depth := 0; depth < maxLogstashConditionalDepth; depth++ {
+		conditional, ok := s.Schema["conditional"]
+		if !ok {
+			t.Fatalf("expected a conditional block at depth %d", depth)
+		}
+		s = conditional.Elem.(*schema.Resource).Schema["then"].Elem.(*schema.Resource)
+	}
+	if _, ok := s.Schema["conditional"]; ok {
+		t.Fatalf("expected no conditional block beyond depth %d", maxLogstashConditionalDepth)
+	}
+}
+
+func TestDataSourceLogstashPipelineConfigRead_errors(t *testing.T) {
+	tests := []struct {
+		plugin   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{"plugin": "stdout", "raw_settings": map[string]interface{}{"codec": "rubydebug {"}},
+			`invalid raw setting "codec"`,
+		},
+		{
+			map[string]interface{}{
+				"plugin":       "stdout",
+				"settings":     map[string]interface{}{"codec": "json"},
+				"raw_settings": map[string]interface{}{"codec": "json"},
+			},
+			`setting "codec" is defined in both settings and raw_settings`,
+		},
+		{
+			map[string]interface{}{"plugin": "stdout", "settings": map[string]interface{}{"message": `'"`}},
+			`setting "message" cannot contain both unescaped single and double quotes`,
+		},
+		{
+			map[string]interface{}{"plugin": `'"`},
+			"Unable to render a valid pipeline definition",
+		},
+		{
+			map[string]interface{}{
+				"plugin":   "stdout",
+				"setting":  []interface{}{map[string]interface{}{"name": "codec", "value": "json"}},
+				"settings": map[string]interface{}{"codec": "json"},
+			},
+			`setting "codec" is defined more than once`,
+		},
+		{
+			map[string]interface{}{
+				"plugin":  "stdout",
+				"setting": []interface{}{map[string]interface{}{"name": "codec", "value": "json {", "raw": true}},
+			},
+			`invalid raw setting "codec"`,
+		},
+		{
+			map[string]interface{}{},
+			"either plugin or conditional must be set",
+		},
+		{
+			map[string]interface{}{
+				"plugin": "stdout",
+				"conditional": []interface{}{
+					map[string]interface{}{"if": "[debug]", "then": []interface{}{map[string]interface{}{"plugin": "stdout"}}},
+				},
+			},
+			"plugin cannot be set along with conditional",
+		},
+		{
+			map[string]interface{}{
+				"conditional": []interface{}{
+					map[string]interface{}{"if": "[debug]", "then": []interface{}{map[string]interface{}{"plugin": "stdout"}}, "else": []interface{}{map[string]interface{}{"plugin": `'"`}}},
+				},
+			},
+			"Unable to render a valid pipeline definition",
+		},
+	}
+
+	for _, test := range tests {
+		d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineConfig().Schema, map[string]interface{}{
+			"output": []interface{}{test.plugin},
+		})
+		diags := dataSourceLogstashPipelineConfigRead(context.Background(), d, nil)
+		if !diags.HasError() {
+			t.Fatalf("expected error for %v", test.plugin)
+		}
+		if message := diags[0].Summary + ": " + diags[0].Detail; !strings.Contains(message, test.expected) {
+			t.Fatalf("expected error to contain %q for %v, got %q", test.expected, test.plugin, message)
+		}
+	}
+}
+
+func TestValidateLogstashCondition(t *testing.T) {
+	if _, errors := validateLogstashCondition(`[type] == "syslog" and "x" in [tags]`, "if"); len(errors) > 0 {
+		t.Fatalf("expected no error, got %v", errors)
+	}
+	if _, errors := validateLogstashCondition(`[type] = "syslog"`, "if"); len(errors) == 0 {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,252 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+)
+
+func dataSourceLogstashPipelineGraph() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceLogstashPipelineGraphRead,
+		Schema: map[string]*schema.Schema{
+			"space_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: `Kibana space of the pipelines, defaults to the provider space_id.`,
+			},
+			"fail_on_issues": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: `Fail instead of warning when dangling send_to addresses, duplicate addresses
+				or cycles are found.`,
+			},
+			"pipelines": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Pipelines with their pipeline-to-pipeline addresses.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pipeline_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"input_addresses": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"output_addresses": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"edges": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Pipelines sending events to another pipeline.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"from": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"to": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"dangling_send_to": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `send_to addresses no pipeline listens to.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pipeline_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"duplicate_addresses": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Addresses listened to by several pipelines.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pipeline_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"cycles": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Groups of pipelines sending events to each other.`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pipeline_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLogstashPipelineGraphRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	spaceID := pipelineSpace(d, c)
+	c = c.WithSpace(spaceID)
+
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	summaries := pipes.Pipelines
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+
+	var nodes []logstash.PipelineNode
+	pipelines := make([]interface{}, 0, len(summaries))
+	for _, p := range summaries {
+		pipeline, err := c.GetLogstashPipeline(ctx, p.ID)
+		if api.IsNotFound(err) {
+			// Deleted in between
+			continue
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		config, err := logstash.Parse(pipeline.Configuration.Pipeline)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Unable to parse pipeline definition",
+				Detail:   fmt.Sprintf("Pipeline %q is ignored in the graph: %s", p.ID, err),
+			})
+			continue
+		}
+		inputs, outputs := logstash.PipelineAddresses(config)
+		nodes = append(nodes, logstash.PipelineNode{ID: p.ID, Inputs: inputs, Outputs: outputs})
+		pipelines = append(pipelines, map[string]interface{}{
+			"pipeline_id":      p.ID,
+			"input_addresses":  inputs,
+			"output_addresses": outputs,
+		})
+	}
+
+	graph := logstash.NewGraph(nodes)
+	edges := make([]interface{}, 0, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		edges = append(edges, map[string]interface{}{
+			"from":    edge.From,
+			"to":      edge.To,
+			"address": edge.Address,
+		})
+	}
+	dangling := make([]interface{}, 0, len(graph.Dangling))
+	for _, address := range graph.Dangling {
+		dangling = append(dangling, map[string]interface{}{
+			"pipeline_id": address.Pipeline,
+			"address":     address.Address,
+		})
+	}
+	duplicates := make([]interface{}, 0, len(graph.Duplicates))
+	for _, address := range graph.Duplicates {
+		duplicates = append(duplicates, map[string]interface{}{
+			"address":      address.Address,
+			"pipeline_ids": address.Pipelines,
+		})
+	}
+	cycles := make([]interface{}, 0, len(graph.Cycles))
+	for _, cycle := range graph.Cycles {
+		cycles = append(cycles, map[string]interface{}{
+			"pipeline_ids": cycle,
+		})
+	}
+
+	for key, value := range map[string]interface{}{
+		"pipelines":           pipelines,
+		"edges":               edges,
+		"dangling_send_to":    dangling,
+		"duplicate_addresses": duplicates,
+		"cycles":              cycles,
+		"space_id":            spaceID,
+	} {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	d.SetId(spaceID)
+
+	return append(diags, pipelineGraphDiagnostics(graph, d.Get("fail_on_issues").(bool))...)
+}
+
+// pipelineGraphDiagnostics reports the issues found in the graph, as errors if failOnIssues is set
+func pipelineGraphDiagnostics(graph *logstash.Graph, failOnIssues bool) diag.Diagnostics {
+	severity := diag.Warning
+	if failOnIssues {
+		severity = diag.Error
+	}
+
+	var diags diag.Diagnostics
+	for _, address := range graph.Dangling {
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "Dangling pipeline address",
+			Detail:   fmt.Sprintf("Pipeline %q sends events to address %q but no pipeline listens to it", address.Pipeline, address.Address),
+		})
+	}
+	for _, address := range graph.Duplicates {
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "Duplicate pipeline address",
+			Detail:   fmt.Sprintf("Address %q is listened to by pipelines %s", address.Address, strings.Join(address.Pipelines, ", ")),
+		})
+	}
+	for _, cycle := range graph.Cycles {
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "Pipeline cycle",
+			Detail:   fmt.Sprintf("Pipelines %s send events to each other", strings.Join(cycle, ", ")),
+		})
+	}
+	return diags
+}
@@ -0,0 +1,79 @@
+package elastic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+func TestDataSourceLogstashPipelineGraphRead(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "beats", api.LogstashConfiguration{
+		Pipeline: `input { beats {} } output { if [type] == "apache" { pipeline { send_to => weblogs } } else { pipeline { send_to => [unknown] } } }`,
+	})
+	kibana.put(api.DefaultSpaceID, "weblogs", api.LogstashConfiguration{
+		Pipeline: `input { pipeline { address => weblogs } } output { pipeline { send_to => archive } }`,
+	})
+	kibana.put(api.DefaultSpaceID, "archive", api.LogstashConfiguration{
+		Pipeline: `input { pipeline { address => archive } } output { pipeline { send_to => weblogs } }`,
+	})
+	kibana.put(api.DefaultSpaceID, "broken", api.LogstashConfiguration{Pipeline: `input {`})
+
+	d := dataSourceLogstashPipelineGraph().TestResourceData()
+	diags := dataSourceLogstashPipelineGraphRead(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+
+	summaries := make([]string, 0, len(diags))
+	for _, diagnostic := range diags {
+		summaries = append(summaries, diagnostic.Summary)
+	}
+	expectedSummaries := []string{"Unable to parse pipeline definition", "Dangling pipeline address", "Pipeline cycle"}
+	if !reflect.DeepEqual(summaries, expectedSummaries) {
+		t.Fatalf("expected diagnostics %v, got %v", expectedSummaries, summaries)
+	}
+
+	expected := map[string]interface{}{
+		"pipelines.#":                    3,
+		"pipelines.1.pipeline_id":        "beats",
+		"pipelines.1.output_addresses":   []interface{}{"weblogs", "unknown"},
+		"edges.#":                        3,
+		"edges.0.from":                   "archive",
+		"edges.0.to":                     "weblogs",
+		"edges.1.from":                   "beats",
+		"edges.1.address":                "weblogs",
+		"dangling_send_to.#":             1,
+		"dangling_send_to.0.pipeline_id": "beats",
+		"dangling_send_to.0.address":     "unknown",
+		"duplicate_addresses.#":          0,
+		"cycles.#":                       1,
+		"cycles.0.pipeline_ids":          []interface{}{"archive", "weblogs"},
+	}
+	for key, value := range expected {
+		if actual := d.Get(key); !reflect.DeepEqual(actual, value) {
+			t.Fatalf("expected %s to be %v, got %v", key, value, actual)
+		}
+	}
+}
+
+func TestDataSourceLogstashPipelineGraphRead_failOnIssues(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "weblogs", api.LogstashConfiguration{Pipeline: `input { pipeline { address => weblogs } }`})
+	kibana.put(api.DefaultSpaceID, "weblogs-copy", api.LogstashConfiguration{Pipeline: `input { pipeline { address => weblogs } }`})
+
+	d := dataSourceLogstashPipelineGraph().TestResourceData()
+	d.Set("fail_on_issues", true)
+	diags := dataSourceLogstashPipelineGraphRead(context.Background(), d, kibana.meta())
+	if len(diags) != 1 || diags[0].Severity != diag.Error || diags[0].Summary != "Duplicate pipeline address" {
+		t.Fatalf("expected a duplicate address error, got %v", diags)
+	}
+	if pipelines := d.Get("duplicate_addresses.0.pipeline_ids"); !reflect.DeepEqual(pipelines, []interface{}{"weblogs", "weblogs-copy"}) {
+		t.Fatalf("unexpected duplicate address pipelines %v", pipelines)
+	}
+}
@@ -0,0 +1,94 @@
+package elastic
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+func dataSourceLogstashPipelineTemplate() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceLogstashPipelineTemplateRead,
+		Schema: map[string]*schema.Schema{
+			"template": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"template", "filename"},
+				Description:  `Pipeline definition template.`,
+			},
+			"filename": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"template", "filename"},
+				Description:  `Path of the pipeline definition template (e.g. "${path.module}/pipeline.conf").`,
+			},
+			"vars": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: `Variables of the template, referencing an undefined variable is an error.`,
+			},
+			"left_delimiter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      logstash.DefaultLeftDelimiter,
+				ValidateFunc: utils.StringIsNotEmpty,
+				Description: `Start of variable references, prefixing it with its first character escapes it
+				(e.g. {{{NAME}} renders {{NAME}}). Logstash ${VAR} references are kept as is with the default delimiters.`,
+			},
+			"right_delimiter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      logstash.DefaultRightDelimiter,
+				ValidateFunc: utils.StringIsNotEmpty,
+				Description:  `End of variable references.`,
+			},
+			"pipeline": {
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: `Rendered pipeline definition, to be used as the pipeline of an
+				elastic_logstash_pipeline resource.`,
+			},
+		},
+	}
+}
+
+func dataSourceLogstashPipelineTemplateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	template := d.Get("template").(string)
+	if filename, ok := d.GetOk("filename"); ok {
+		content, err := ioutil.ReadFile(filename.(string))
+		if err != nil {
+			return diag.Errorf("Unable to read template: %s", err)
+		}
+		template = string(content)
+	}
+
+	vars := make(map[string]string)
+	for name, value := range d.Get("vars").(map[string]interface{}) {
+		vars[name] = value.(string)
+	}
+
+	pipeline, err := logstash.RenderTemplate(template, d.Get("left_delimiter").(string), d.Get("right_delimiter").(string), vars)
+	if err != nil {
+		return diag.Errorf("Unable to render template: %s", err)
+	}
+	if _, err := logstash.Parse(pipeline); err != nil {
+		return diag.Errorf("Invalid rendered pipeline definition: %s", err)
+	}
+
+	if err := d.Set("pipeline", pipeline); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(pipeline))))
+
+	return diags
+}
@@ -0,0 +1,88 @@
+package elastic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceLogstashPipelineTemplateRead(t *testing.T) {
+	tests := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{
+				"template": `output { elasticsearch { index => "filebeat-%{+yyyy.MM.dd}" cloud_id => "{{CLOUD_ID}}" cloud_auth => "${CLOUD_AUTH}" } }`,
+				"vars":     map[string]interface{}{"CLOUD_ID": "my-cloud"},
+			},
+			`output { elasticsearch { index => "filebeat-%{+yyyy.MM.dd}" cloud_id => "my-cloud" cloud_auth => "${CLOUD_AUTH}" } }`,
+		},
+		{
+			map[string]interface{}{
+				"template":        `output { elasticsearch { cloud_id => "<<CLOUD_ID>>" cloud_auth => "${CLOUD_AUTH}" } }`,
+				"vars":            map[string]interface{}{"CLOUD_ID": "my-cloud"},
+				"left_delimiter":  "<<",
+				"right_delimiter": ">>",
+			},
+			`output { elasticsearch { cloud_id => "my-cloud" cloud_auth => "${CLOUD_AUTH}" } }`,
+		},
+	}
+
+	for _, test := range tests {
+		d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineTemplate().Schema, test.config)
+		diags := dataSourceLogstashPipelineTemplateRead(context.Background(), d, nil)
+		if diags.HasError() {
+			t.Fatalf("expected no error for %v, got %v", test.config, diags)
+		}
+		if pipeline := d.Get("pipeline"); pipeline != test.expected {
+			t.Fatalf("expected %q for %v, got %q", test.expected, test.config, pipeline)
+		}
+	}
+}
+
+func TestDataSourceLogstashPipelineTemplateRead_file(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineTemplate().Schema, map[string]interface{}{
+		"filename": "../example/pipeline.conf",
+		"vars":     map[string]interface{}{"CLOUD_ID": "my-cloud"},
+	})
+	diags := dataSourceLogstashPipelineTemplateRead(context.Background(), d, nil)
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	pipeline := d.Get("pipeline").(string)
+	if !strings.Contains(pipeline, `index => "filebeat-%{+yyyy.MM.dd}"`) || !strings.Contains(pipeline, `cloud_id => "my-cloud"`) ||
+		!strings.Contains(pipeline, `cloud_auth => "${CLOUD_AUTH}"`) {
+		t.Fatalf("unexpected pipeline %q", pipeline)
+	}
+}
+
+func TestDataSourceLogstashPipelineTemplateRead_errors(t *testing.T) {
+	tests := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{"template": `output { elasticsearch { cloud_id => "{{CLOUD_ID}}" } }`},
+			`Unable to render template: line 1, column 39: undefined variable "CLOUD_ID"`,
+		},
+		{
+			map[string]interface{}{"filename": "does-not-exist.conf"},
+			`Unable to read template`,
+		},
+		{
+			map[string]interface{}{"template": `output { {{PLUGIN}} }`, "vars": map[string]interface{}{"PLUGIN": "std out"}},
+			`Invalid rendered pipeline definition`,
+		},
+	}
+
+	for _, test := range tests {
+		d := schema.TestResourceDataRaw(t, dataSourceLogstashPipelineTemplate().Schema, test.config)
+		diags := dataSourceLogstashPipelineTemplateRead(context.Background(), d, nil)
+		if !diags.HasError() || !strings.Contains(diags[0].Summary, test.expected) {
+			t.Fatalf("expected error %q for %v, got %v", test.expected, test.config, diags)
+		}
+	}
+}
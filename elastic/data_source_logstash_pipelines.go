@@ -0,0 +1,236 @@
+package elastic
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+func dataSourceLogstashPipelines() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceLogstashPipelinesRead,
+		Schema: map[string]*schema.Schema{
+			"space_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: `Kibana space of the pipelines, defaults to the provider space_id.`,
+			},
+			"id_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: utils.IsValidRegExp,
+				Description:  `Only keep pipelines whose ID matches this regular expression.`,
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: `Only keep pipelines last modified by this user.`,
+			},
+			"modified_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: utils.IsRFC3339Time,
+				Description:  `Only keep pipelines modified after this date (RFC3339).`,
+			},
+			"modified_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: utils.IsRFC3339Time,
+				Description:  `Only keep pipelines modified before this date (RFC3339).`,
+			},
+			"include_definitions": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: `Fetch the definition and settings of every matching pipeline
+				(one additional API call per pipeline).`,
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: `IDs of the matching pipelines.`,
+			},
+			"pipelines": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pipeline_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Pipeline name.`,
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Pipeline description.`,
+						},
+						"username": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `User who last modified the pipeline.`,
+						},
+						"last_modified": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Date of the last modification.`,
+						},
+						"pipeline": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Pipeline definition, only set with include_definitions.`,
+						},
+						"settings": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: `Pipeline settings, only set with include_definitions.`,
+							Elem: &schema.Resource{
+								Schema: dataSourceLogstashPipelineSettingsSchema(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// logstashPipelinesFilter keeps the pipelines matching all the configured criteria
+type logstashPipelinesFilter struct {
+	idRegex        *regexp.Regexp
+	username       string
+	modifiedAfter  *time.Time
+	modifiedBefore *time.Time
+}
+
+func dataSourceLogstashPipelinesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	spaceID := pipelineSpace(d, c)
+	c = c.WithSpace(spaceID)
+
+	filter, err := logstashPipelinesFilterData(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	matching := filter.apply(pipes.Pipelines)
+	sort.Slice(matching, func(i, j int) bool { return matching[i].ID < matching[j].ID })
+
+	ids := make([]interface{}, 0, len(matching))
+	pipelines := make([]interface{}, 0, len(matching))
+	for _, p := range matching {
+		pl := map[string]interface{}{
+			"pipeline_id":   p.ID,
+			"description":   p.Description,
+			"username":      p.Username,
+			"last_modified": p.LastModified,
+		}
+		if d.Get("include_definitions").(bool) {
+			pipeline, err := c.GetLogstashPipeline(ctx, p.ID)
+			if api.IsNotFound(err) {
+				// Deleted in between
+				continue
+			}
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			pl["pipeline"] = pipeline.Configuration.Pipeline
+			pl["settings"] = flattenSettings(pipeline.Configuration.Settings)
+		}
+		ids = append(ids, p.ID)
+		pipelines = append(pipelines, pl)
+	}
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("pipelines", pipelines); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("space_id", spaceID); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(spaceID)
+
+	return diags
+}
+
+func logstashPipelinesFilterData(d *schema.ResourceData) (*logstashPipelinesFilter, error) {
+	filter := &logstashPipelinesFilter{}
+	if v, ok := d.GetOk("id_regex"); ok {
+		r, err := regexp.Compile(v.(string))
+		if err != nil {
+			return nil, err
+		}
+		filter.idRegex = r
+	}
+	if v, ok := d.GetOk("username"); ok {
+		filter.username = v.(string)
+	}
+	if v, ok := d.GetOk("modified_after"); ok {
+		t, err := time.Parse(time.RFC3339, v.(string))
+		if err != nil {
+			return nil, err
+		}
+		filter.modifiedAfter = &t
+	}
+	if v, ok := d.GetOk("modified_before"); ok {
+		t, err := time.Parse(time.RFC3339, v.(string))
+		if err != nil {
+			return nil, err
+		}
+		filter.modifiedBefore = &t
+	}
+	return filter, nil
+}
+
+func (f *logstashPipelinesFilter) apply(pipelines []api.LogstashPipelineSummary) []api.LogstashPipelineSummary {
+	var matching []api.LogstashPipelineSummary
+	for _, p := range pipelines {
+		if f.matches(p) {
+			matching = append(matching, p)
+		}
+	}
+	return matching
+}
+
+func (f *logstashPipelinesFilter) matches(p api.LogstashPipelineSummary) bool {
+	if f.idRegex != nil && !f.idRegex.MatchString(p.ID) {
+		return false
+	}
+	if len(f.username) > 0 && f.username != p.Username {
+		return false
+	}
+	if f.modifiedAfter == nil && f.modifiedBefore == nil {
+		return true
+	}
+	// Pipelines without a valid modification date cannot match a time window
+	modified, err := time.Parse(time.RFC3339, p.LastModified)
+	if err != nil {
+		return false
+	}
+	if f.modifiedAfter != nil && !modified.After(*f.modifiedAfter) {
+		return false
+	}
+	if f.modifiedBefore != nil && !modified.Before(*f.modifiedBefore) {
+		return false
+	}
+	return true
+}
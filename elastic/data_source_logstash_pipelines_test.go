@@ -0,0 +1,70 @@
+package elastic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+func TestDataSourceLogstashPipelinesRead(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "filebeat-k8s", api.LogstashConfiguration{Pipeline: "input { beats {} }", Username: "alice", Settings: &api.Settings{PipelineWorkers: 2}})
+	kibana.put(api.DefaultSpaceID, "filebeat-vm", api.LogstashConfiguration{Pipeline: "input { beats {} }", Username: "bob"})
+	kibana.put(api.DefaultSpaceID, "metricbeat", api.LogstashConfiguration{Pipeline: "input { beats {} }", Username: "alice"})
+	kibana.put("team-a", "filebeat-team", api.LogstashConfiguration{Pipeline: "input { beats {} }", Username: "alice"})
+
+	tests := []struct {
+		config   map[string]interface{}
+		expected []interface{}
+	}{
+		{map[string]interface{}{}, []interface{}{"filebeat-k8s", "filebeat-vm", "metricbeat"}},
+		{map[string]interface{}{"id_regex": "^filebeat-"}, []interface{}{"filebeat-k8s", "filebeat-vm"}},
+		{map[string]interface{}{"username": "alice"}, []interface{}{"filebeat-k8s", "metricbeat"}},
+		{map[string]interface{}{"id_regex": "^filebeat-", "username": "alice"}, []interface{}{"filebeat-k8s"}},
+		{map[string]interface{}{"modified_after": "2000-01-01T00:00:00Z"}, []interface{}{"filebeat-k8s", "filebeat-vm", "metricbeat"}},
+		{map[string]interface{}{"modified_before": "2000-01-01T00:00:00Z"}, []interface{}{}},
+		{map[string]interface{}{"space_id": "team-a"}, []interface{}{"filebeat-team"}},
+	}
+
+	for _, test := range tests {
+		d := dataSourceLogstashPipelines().TestResourceData()
+		for key, value := range test.config {
+			d.Set(key, value)
+		}
+
+		diags := dataSourceLogstashPipelinesRead(context.Background(), d, kibana.meta())
+		if diags.HasError() {
+			t.Fatalf("expected no error for %v, got %v", test.config, diags)
+		}
+		if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, test.expected) {
+			t.Fatalf("expected %v for %v, got %v", test.expected, test.config, ids)
+		}
+	}
+}
+
+func TestDataSourceLogstashPipelinesRead_includeDefinitions(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "filebeat", api.LogstashConfiguration{Pipeline: "input { beats {} }", Settings: &api.Settings{PipelineWorkers: 2}})
+
+	d := dataSourceLogstashPipelines().TestResourceData()
+	diags := dataSourceLogstashPipelinesRead(context.Background(), d, kibana.meta())
+	if diags.HasError() || d.Get("pipelines.0.pipeline") != "" {
+		t.Fatalf("expected definitions not to be fetched, got %v (%v)", d.Get("pipelines.0.pipeline"), diags)
+	}
+
+	d.Set("include_definitions", true)
+	diags = dataSourceLogstashPipelinesRead(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if d.Get("pipelines.0.pipeline") != "input { beats {} }" || d.Get("pipelines.0.settings.0.workers") != 2 {
+		t.Fatalf("expected definition to be fetched, got %v", d.Get("pipelines"))
+	}
+	if d.Get("pipelines.0.username") != "elastic" || d.Get("pipelines.0.last_modified") == "" {
+		t.Fatalf("expected list attributes to be set, got %v", d.Get("pipelines"))
+	}
+}
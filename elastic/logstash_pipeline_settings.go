@@ -0,0 +1,240 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/internal/pipelinesettings"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+// resourceLogstashPipelineSettingsSchema returns the settings of a pipeline with their Logstash default values
+func resourceLogstashPipelineSettingsSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"batch_delay": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultPipelineBatchDelay,
+			ValidateFunc: utils.IntAtLeast(1),
+			Description: `This setting adjusts the latency of the Logstash pipeline.
+			Pipeline batch delay is the maximum amount of time in milliseconds that
+			Logstash waits for new messages after receiving an event in the current
+			pipeline worker thread.`,
+		},
+		"batch_size": {
+			Type:         schema.TypeInt,
+			Default:      api.DefaultPipelineBatchSize,
+			Optional:     true,
+			ValidateFunc: utils.IntAtLeast(1),
+			Description: `This setting defines the maximum number of events an
+			individual worker thread collects before attempting to execute filters
+			and outputs. Larger batch sizes are generally more efficient, but
+			increase memory overhead.`,
+		},
+		"workers": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultPipelineWorkers,
+			ValidateFunc: utils.IntAtLeast(1),
+			Description: `This setting determines how many threads to run for filter
+			 and output processing.`,
+		},
+		"ordered": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      api.DefaultPipelineOrdered,
+			ValidateFunc: utils.StringInSlice([]string{"auto", "true", "false"}, false),
+			Description: `Preserve the events order: true forces it (and a single worker),
+			auto enables it when workers is 1.`,
+		},
+		"ecs_compatibility": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: utils.StringInSlice([]string{"disabled", "v1", "v8"}, false),
+			Description: `ECS compatibility mode of the plugins (disabled, v1 or v8),
+			the default depends on the Logstash version.`,
+		},
+		"queue_checkpoint_writes": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultQueueCheckpointWrites,
+			ValidateFunc: utils.IntAtLeast(1),
+			Description: `This setting specifies the maximum number of events that
+			 may be written to disk before forcing a checkpoint. `,
+		},
+		"queue_checkpoint_acks": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultQueueCheckpointAcks,
+			ValidateFunc: utils.IntAtLeast(0),
+			Description: `The maximum number of acked events before forcing a checkpoint
+			of the persistent queue, 0 for unlimited.`,
+		},
+		"queue_checkpoint_interval": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultQueueCheckpointInterval,
+			ValidateFunc: utils.IntAtLeast(0),
+			Description: `The interval in milliseconds at which a checkpoint of the head page
+			of the persistent queue is forced, 0 for no periodic checkpoint.`,
+		},
+		"queue_checkpoint_retry": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     api.DefaultQueueCheckpointRetry,
+			Description: `Retry checkpoint writes of the persistent queue once they failed.`,
+		},
+		"queue_max_bytes": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          api.DefaultQueueMaxBytes,
+			ValidateFunc:     utils.IsByteSize,
+			DiffSuppressFunc: utils.SuppressEquivalentByteSize,
+			Description:      `The total capacity of the queue in number of bytes.`,
+		},
+		"queue_max_events": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultQueueMaxEvents,
+			ValidateFunc: utils.IntAtLeast(0),
+			Description:  `The maximum number of unread events in the persistent queue, 0 for unlimited.`,
+		},
+		"queue_page_capacity": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          api.DefaultQueuePageCapacity,
+			ValidateFunc:     utils.IsByteSize,
+			DiffSuppressFunc: utils.SuppressEquivalentByteSize,
+			Description:      `The size of the page data files of the persistent queue.`,
+		},
+		"queue_drain": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     api.DefaultQueueDrain,
+			Description: `Wait for the persistent queue to be drained before shutting down.`,
+		},
+		"queue_type": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      api.DefaultQueueType,
+			Description:  `Specify persisted to enable persistent queues.`,
+			ValidateFunc: utils.StringInSlice([]string{"memory", "persisted"}, false),
+		},
+		"dead_letter_queue_enable": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     api.DefaultDeadLetterQueueEnable,
+			Description: `Enable the dead letter queue, for the plugins supporting it.`,
+		},
+		"dead_letter_queue_max_bytes": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          api.DefaultDeadLetterQueueMaxBytes,
+			ValidateFunc:     utils.IsByteSize,
+			DiffSuppressFunc: utils.SuppressEquivalentByteSize,
+			Description:      `The maximum size of the dead letter queue.`,
+		},
+		"dead_letter_queue_storage_policy": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      api.DefaultDeadLetterQueueStoragePolicy,
+			ValidateFunc: utils.StringInSlice([]string{"drop_newer", "drop_older"}, false),
+			Description: `Events dropped when the dead letter queue is full: drop_newer
+			(the new events) or drop_older (the oldest events).`,
+		},
+		"dead_letter_queue_flush_interval": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      api.DefaultDeadLetterQueueFlushInterval,
+			ValidateFunc: utils.IntAtLeast(1),
+			Description: `The interval in milliseconds without new dead letter queue entries
+			after which the current segment is written.`,
+		},
+	}
+}
+
+// dataSourceLogstashPipelineSettingsSchema returns the computed settings of a pipeline
+func dataSourceLogstashPipelineSettingsSchema() map[string]*schema.Schema {
+	s := make(map[string]*schema.Schema)
+	for name, setting := range resourceLogstashPipelineSettingsSchema() {
+		s[name] = &schema.Schema{
+			Type:        setting.Type,
+			Computed:    true,
+			Description: setting.Description,
+		}
+	}
+	return s
+}
+
+// expandSettings returns the settings of the settings block, the Logstash default values when it is not specified:
+// settings are always sent since Kibana does not return the settings applied by default
+func expandSettings(v []interface{}) *api.Settings {
+	i := pipelinesettings.Defaults()
+	if len(v) > 0 && v[0] != nil {
+		i = v[0].(map[string]interface{})
+	}
+
+	var settings api.Settings
+	settings.PipelineBatchDelay = i["batch_delay"].(int)
+	settings.PipelineWorkers = i["workers"].(int)
+	settings.PipelineBatchSize = i["batch_size"].(int)
+	settings.PipelineOrdered = i["ordered"].(string)
+	settings.PipelineECSCompatibility = i["ecs_compatibility"].(string)
+	settings.QueueCheckpointWrites = i["queue_checkpoint_writes"].(int)
+	settings.QueueCheckpointAcks = api.Int(i["queue_checkpoint_acks"].(int))
+	settings.QueueCheckpointInterval = api.Int(i["queue_checkpoint_interval"].(int))
+	settings.QueueCheckpointRetry = api.Bool(i["queue_checkpoint_retry"].(bool))
+	settings.QueueMaxBytes = normalizeByteSize(i["queue_max_bytes"].(string))
+	settings.QueueMaxEvents = api.Int(i["queue_max_events"].(int))
+	settings.QueuePageCapacity = normalizeByteSize(i["queue_page_capacity"].(string))
+	settings.QueueDrain = api.Bool(i["queue_drain"].(bool))
+	settings.QueueType = i["queue_type"].(string)
+	settings.DeadLetterQueueEnable = api.Bool(i["dead_letter_queue_enable"].(bool))
+	settings.DeadLetterQueueMaxBytes = normalizeByteSize(i["dead_letter_queue_max_bytes"].(string))
+	settings.DeadLetterQueueStoragePolicy = i["dead_letter_queue_storage_policy"].(string)
+	settings.DeadLetterQueueFlushInterval = i["dead_letter_queue_flush_interval"].(int)
+	return &settings
+}
+
+// flattenSettings returns the settings block matching settings, see pipelinesettings.Flatten
+func flattenSettings(settings *api.Settings) []interface{} {
+	return []interface{}{pipelinesettings.Flatten(settings)}
+}
+
+// customizeDiffQueueCapacity rejects persistent queues smaller than their page size, Logstash would not start the pipeline
+func customizeDiffQueueCapacity(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	v := d.Get("settings").([]interface{})
+	if len(v) == 0 || v[0] == nil {
+		return nil
+	}
+	settings := v[0].(map[string]interface{})
+	if settings["queue_type"] != "persisted" {
+		return nil
+	}
+
+	maxBytes := settings["queue_max_bytes"].(string)
+	pageCapacity := settings["queue_page_capacity"].(string)
+	// Invalid sizes are reported by validation, unknown values are empty
+	maxBytesSize, err := utils.ParseByteSize(maxBytes)
+	if err != nil {
+		return nil
+	}
+	pageCapacitySize, err := utils.ParseByteSize(pageCapacity)
+	if err != nil {
+		return nil
+	}
+	if maxBytesSize < pageCapacitySize {
+		return fmt.Errorf("settings: queue_max_bytes (%s) must be greater than or equal to queue_page_capacity (%s)", maxBytes, pageCapacity)
+	}
+	return nil
+}
+
+// normalizeByteSize returns the byte size as accepted by Logstash (e.g. 1 GB -> 1gb), invalid sizes are rejected by validation
+func normalizeByteSize(v string) string {
+	if normalized, err := utils.NormalizeByteSize(v); err == nil {
+		return normalized
+	}
+	return v
+}
@@ -0,0 +1,296 @@
+package elastic
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/internal/pipelinesettings"
+)
+
+func TestExpandFlattenSettings(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, map[string]interface{}{
+		"pipeline_id": "filebeat",
+		"pipeline":    "input { beats {} }",
+		"settings": []interface{}{
+			map[string]interface{}{
+				"workers":                  4,
+				"ordered":                  "false",
+				"queue_type":               "persisted",
+				"queue_checkpoint_acks":    0,
+				"dead_letter_queue_enable": true,
+			},
+		},
+	})
+
+	settings := expandSettings(d.Get("settings").([]interface{}))
+	expected := &api.Settings{
+		PipelineBatchDelay:           50,
+		PipelineBatchSize:            125,
+		PipelineWorkers:              4,
+		PipelineOrdered:              "false",
+		QueueCheckpointWrites:        1024,
+		QueueCheckpointAcks:          api.Int(0),
+		QueueCheckpointInterval:      api.Int(1000),
+		QueueCheckpointRetry:         api.Bool(true),
+		QueueMaxBytes:                "1gb",
+		QueueMaxEvents:               api.Int(0),
+		QueuePageCapacity:            "64mb",
+		QueueDrain:                   api.Bool(false),
+		QueueType:                    "persisted",
+		DeadLetterQueueEnable:        api.Bool(true),
+		DeadLetterQueueMaxBytes:      "1024mb",
+		DeadLetterQueueStoragePolicy: "drop_newer",
+		DeadLetterQueueFlushInterval: 5000,
+	}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, settings)
+	}
+
+	if flattened := flattenSettings(settings); !reflect.DeepEqual(flattened, d.Get("settings")) {
+		t.Fatalf("expected %v, got %v", d.Get("settings"), flattened)
+	}
+}
+
+func TestFlattenSettings_missing(t *testing.T) {
+	// Pipeline created by a previous version of the provider, or in Kibana UI
+	flattened := flattenSettings(api.NewLogstashPipelineSettings(50, 125, 1, 1024, "1gb", "memory"))
+	s := flattened[0].(map[string]interface{})
+
+	expected := map[string]interface{}{
+		"ordered":                          "auto",
+		"ecs_compatibility":                "",
+		"queue_checkpoint_acks":            1024,
+		"queue_checkpoint_interval":        1000,
+		"queue_checkpoint_retry":           true,
+		"queue_max_events":                 0,
+		"queue_page_capacity":              "64mb",
+		"queue_drain":                      false,
+		"dead_letter_queue_enable":         false,
+		"dead_letter_queue_max_bytes":      "1024mb",
+		"dead_letter_queue_storage_policy": "drop_newer",
+		"dead_letter_queue_flush_interval": 5000,
+	}
+	for key, value := range expected {
+		if s[key] != value {
+			t.Fatalf("expected %s to be %v, got %v", key, value, s[key])
+		}
+	}
+}
+
+func TestDataSourceLogstashPipelineSettingsSchema(t *testing.T) {
+	resourceSchema := resourceLogstashPipelineSettingsSchema()
+	dataSourceSchema := dataSourceLogstashPipelineSettingsSchema()
+	if len(resourceSchema) != len(dataSourceSchema) {
+		t.Fatalf("expected %d settings, got %d", len(resourceSchema), len(dataSourceSchema))
+	}
+	for name, setting := range dataSourceSchema {
+		if !setting.Computed || setting.Optional || setting.Default != nil || setting.Type != resourceSchema[name].Type {
+			t.Fatalf("expected %s to be computed with the resource type, got %+v", name, setting)
+		}
+	}
+}
+
+func TestExpandSettings_byteSizes(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, map[string]interface{}{
+		"settings": []interface{}{
+			map[string]interface{}{
+				"queue_max_bytes":             "2 GB",
+				"queue_page_capacity":         "128MB",
+				"dead_letter_queue_max_bytes": "1gb",
+			},
+		},
+	})
+
+	settings := expandSettings(d.Get("settings").([]interface{}))
+	if settings.QueueMaxBytes != "2gb" || settings.QueuePageCapacity != "128mb" || settings.DeadLetterQueueMaxBytes != "1gb" {
+		t.Fatalf("expected byte sizes to be normalized, got %+v", settings)
+	}
+}
+
+func TestCustomizeDiffQueueCapacity(t *testing.T) {
+	tests := []struct {
+		settings map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"queue_type": "persisted", "queue_max_bytes": "1gb", "queue_page_capacity": "64mb"}, ""},
+		{map[string]interface{}{"queue_type": "persisted", "queue_max_bytes": "64MB", "queue_page_capacity": "64mb"}, ""},
+		{map[string]interface{}{"queue_type": "persisted", "queue_max_bytes": "32mb"}, "queue_max_bytes (32mb) must be greater than or equal to queue_page_capacity (64mb)"},
+		// Only persistent queues are concerned
+		{map[string]interface{}{"queue_type": "memory", "queue_max_bytes": "32mb"}, ""},
+	}
+
+	for _, test := range tests {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"pipeline_id": "filebeat",
+			"pipeline":    "input { beats {} }",
+			"settings":    []interface{}{test.settings},
+		})
+		_, err := resourceLogstashPipeline().Diff(context.Background(), nil, config, &providerMeta{})
+		if test.expected == "" && err != nil {
+			t.Fatalf("expected no error for %v, got %v", test.settings, err)
+		}
+		if test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+			t.Fatalf("expected error %q for %v, got %v", test.expected, test.settings, err)
+		}
+	}
+}
+
+func TestSettingsByteSizeDiffSuppression(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "filebeat",
+		Attributes: map[string]string{
+			"pipeline_id":                                 "filebeat",
+			"pipeline":                                    "input { beats {} }",
+			"semantic_diff":                               "true",
+			"force_overwrite":                             "false",
+			"pipeline_inputs_addresses.#":                 "0",
+			"pipeline_output_addresses.#":                 "0",
+			"settings.#":                                  "1",
+			"settings.0.queue_max_bytes":                  "1gb",
+			"settings.0.queue_type":                       "memory",
+			"settings.0.batch_delay":                      "50",
+			"settings.0.batch_size":                       "125",
+			"settings.0.workers":                          "1",
+			"settings.0.ordered":                          "auto",
+			"settings.0.queue_page_capacity":              "64mb",
+			"settings.0.queue_checkpoint_writes":          "1024",
+			"settings.0.queue_checkpoint_acks":            "1024",
+			"settings.0.queue_checkpoint_interval":        "1000",
+			"settings.0.queue_checkpoint_retry":           "true",
+			"settings.0.queue_max_events":                 "0",
+			"settings.0.queue_drain":                      "false",
+			"settings.0.dead_letter_queue_enable":         "false",
+			"settings.0.dead_letter_queue_max_bytes":      "1024mb",
+			"settings.0.dead_letter_queue_storage_policy": "drop_newer",
+			"settings.0.dead_letter_queue_flush_interval": "5000",
+		},
+	}
+
+	tests := []struct {
+		maxBytes string
+		changed  bool
+	}{
+		{"1gb", false},
+		{"1024mb", false},
+		{"1 GB", false},
+		{"2gb", true},
+	}
+
+	for _, test := range tests {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"pipeline_id": "filebeat",
+			"pipeline":    "input { beats {} }",
+			"settings":    []interface{}{map[string]interface{}{"queue_max_bytes": test.maxBytes}},
+		})
+		diff, err := resourceLogstashPipeline().Diff(context.Background(), state, config, &providerMeta{})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		changed := diff != nil && len(diff.Attributes) > 0
+		if changed != test.changed {
+			t.Fatalf("expected changed to be %t for %q, got %v", test.changed, test.maxBytes, diff)
+		}
+	}
+}
+
+func TestExpandFlattenSettings_defaults(t *testing.T) {
+	expected := &api.Settings{
+		PipelineBatchDelay:           50,
+		PipelineBatchSize:            125,
+		PipelineWorkers:              1,
+		PipelineOrdered:              "auto",
+		QueueCheckpointWrites:        1024,
+		QueueCheckpointAcks:          api.Int(1024),
+		QueueCheckpointInterval:      api.Int(1000),
+		QueueCheckpointRetry:         api.Bool(true),
+		QueueMaxBytes:                "1gb",
+		QueueMaxEvents:               api.Int(0),
+		QueuePageCapacity:            "64mb",
+		QueueDrain:                   api.Bool(false),
+		QueueType:                    "memory",
+		DeadLetterQueueEnable:        api.Bool(false),
+		DeadLetterQueueMaxBytes:      "1024mb",
+		DeadLetterQueueStoragePolicy: "drop_newer",
+		DeadLetterQueueFlushInterval: 5000,
+	}
+	for _, v := range [][]interface{}{nil, {}, {nil}} {
+		if settings := expandSettings(v); !reflect.DeepEqual(settings, expected) {
+			t.Fatalf("expected defaults for %v, got %+v", v, settings)
+		}
+	}
+
+	// Kibana returns null settings when none were specified
+	if flattened := flattenSettings(nil); !reflect.DeepEqual(flattened, flattenSettings(expected)) {
+		t.Fatalf("expected null settings to be flattened as defaults, got %v", flattened)
+	}
+	if flattened := flattenSettings(&api.Settings{}); !reflect.DeepEqual(flattened, flattenSettings(expected)) {
+		t.Fatalf("expected empty settings to be flattened as defaults, got %v", flattened)
+	}
+}
+
+func TestSettingsSchemaDefaults(t *testing.T) {
+	defaults := pipelinesettings.Defaults()
+	for name, setting := range resourceLogstashPipelineSettingsSchema() {
+		expected := setting.Default
+		if expected == nil {
+			expected = setting.ZeroValue()
+		}
+		if defaults[name] != expected {
+			t.Fatalf("expected default %v for %s, got %v", expected, name, defaults[name])
+		}
+	}
+	if len(defaults) != len(resourceLogstashPipelineSettingsSchema()) {
+		t.Fatalf("expected a default for each setting, got %v", defaults)
+	}
+}
+
+func TestResourceLogstashPipeline_withoutSettings(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	raw := map[string]interface{}{
+		"pipeline_id": "filebeat",
+		"pipeline":    "input { beats {} }",
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, raw)
+	diags := resourceLogstashPipelineCreate(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if sent := kibana.get(api.DefaultSpaceID, "filebeat").Settings; sent == nil || sent.QueueType != "memory" || sent.DeadLetterQueueEnable == nil {
+		t.Fatalf("expected default settings to be sent, got %+v", sent)
+	}
+	if d.Get("settings.0.workers") != 1 || d.Get("settings.0.queue_max_bytes") != "1gb" {
+		t.Fatalf("expected default settings to be read, got %v", d.Get("settings"))
+	}
+
+	// Omitting the block must produce an empty plan
+	diff, err := resourceLogstashPipeline().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Fatalf("expected empty plan, got %v", diff.Attributes)
+	}
+
+	// Same for pipelines created in Kibana UI, without settings: only the modification is acknowledged
+	kibana.put(api.DefaultSpaceID, "filebeat", api.LogstashConfiguration{Pipeline: "input { beats {} }"})
+	diags = resourceLogstashPipelineRead(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	diff, err = resourceLogstashPipeline().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for key := range diff.Attributes {
+		if key != "applied_last_modified" && key != "applied_username" {
+			t.Fatalf("expected no settings change, got %v", diff.Attributes)
+		}
+	}
+}
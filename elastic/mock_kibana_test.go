@@ -0,0 +1,170 @@
+package elastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+// mockKibana is an in-memory stand-in for the Kibana Logstash centralized management API
+type mockKibana struct {
+	*httptest.Server
+	mu sync.Mutex
+	// pipelines are indexed by space then by pipeline ID
+	pipelines    map[string]map[string]*mockPipeline
+	listRequests int
+	// putStatus, when set, is the error status returned to pipeline writes
+	putStatus int
+}
+
+type mockPipeline struct {
+	config       api.LogstashConfiguration
+	lastModified string
+}
+
+func newMockKibana() *mockKibana {
+	m := &mockKibana{pipelines: make(map[string]map[string]*mockPipeline)}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	return m
+}
+
+// client returns an API client targeting the mock
+func (m *mockKibana) client() *api.Client {
+	c := api.NewClient("elastic:changeme", m.URL)
+	c.MaxRetries = 0
+	return c
+}
+
+// meta returns the provider configuration targeting the mock, as passed to resources and data sources
+func (m *mockKibana) meta() *providerMeta {
+	return &providerMeta{client: m.client(), secretDetection: secretDetectionWarn}
+}
+
+// providerConfig returns the provider block targeting the mock
+func (m *mockKibana) providerConfig() string {
+	return fmt.Sprintf(`
+	provider "elastic" {
+		kibana_url = "%s"
+		cloud_auth = "elastic:changeme"
+	}
+	`, m.URL)
+}
+
+// put stores a pipeline as if it was created in Kibana UI
+func (m *mockKibana) put(spaceID, id string, config api.LogstashConfiguration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if config.Username == "" {
+		config.Username = "elastic"
+	}
+	if m.pipelines[spaceID] == nil {
+		m.pipelines[spaceID] = make(map[string]*mockPipeline)
+	}
+	m.pipelines[spaceID][id] = &mockPipeline{config: config, lastModified: time.Now().UTC().Format(time.RFC3339Nano)}
+}
+
+// get returns a stored pipeline, nil if it does not exist
+func (m *mockKibana) get(spaceID, id string) *api.LogstashConfiguration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.pipelines[spaceID][id]; ok {
+		config := p.config
+		return &config
+	}
+	return nil
+}
+
+// delete removes a pipeline as if it was deleted in Kibana UI
+func (m *mockKibana) delete(spaceID, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pipelines[spaceID], id)
+}
+
+func (m *mockKibana) handle(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	spaceID := api.DefaultSpaceID
+	if strings.HasPrefix(path, "/s/") {
+		parts := strings.SplitN(strings.TrimPrefix(path, "/s/"), "/", 2)
+		spaceID, path = parts[0], "/"+parts[1]
+	}
+
+	switch {
+	case path == "/api/logstash/pipelines" && r.Method == http.MethodGet:
+		m.list(w, spaceID)
+	case strings.HasPrefix(path, "/api/logstash/pipeline/"):
+		id := strings.TrimPrefix(path, "/api/logstash/pipeline/")
+		switch r.Method {
+		case http.MethodGet:
+			if config := m.get(spaceID, id); config != nil {
+				json.NewEncoder(w).Encode(config)
+				return
+			}
+			// The real API answers 500 for unknown pipelines, hence the list lookups
+			m.error(w, http.StatusNotFound)
+		case http.MethodPut:
+			if status := m.putError(); status != 0 {
+				m.error(w, status)
+				return
+			}
+			var config api.LogstashConfiguration
+			if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+				m.error(w, http.StatusBadRequest)
+				return
+			}
+			m.put(spaceID, id, config)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if m.get(spaceID, id) == nil {
+				m.error(w, http.StatusNotFound)
+				return
+			}
+			m.delete(spaceID, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			m.error(w, http.StatusMethodNotAllowed)
+		}
+	default:
+		m.error(w, http.StatusNotFound)
+	}
+}
+
+// failPuts makes the pipeline writes fail with status, 0 to accept them again
+func (m *mockKibana) failPuts(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putStatus = status
+}
+
+func (m *mockKibana) putError() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.putStatus
+}
+
+func (m *mockKibana) list(w http.ResponseWriter, spaceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listRequests++
+	res := api.LogstashPipelines{}
+	for id, p := range m.pipelines[spaceID] {
+		res.Pipelines = append(res.Pipelines, api.LogstashPipelineSummary{
+			ID:           id,
+			Description:  p.config.Description,
+			LastModified: p.lastModified,
+			Username:     p.config.Username,
+		})
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (m *mockKibana) error(w http.ResponseWriter, status int) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"statusCode":%d,"error":%q,"message":%q}`, status, http.StatusText(status), http.StatusText(status))
+}
@@ -2,57 +2,248 @@ package elastic
 
 import (
 	"context"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
 )
 
+// Secret detection modes
+const (
+	secretDetectionOff   = "off"
+	secretDetectionWarn  = "warn"
+	secretDetectionError = "error"
+)
+
+// providerConnectionNames names the connection settings in errors after the provider attributes
+var providerConnectionNames = api.ConnectionNames{
+	KibanaURL:    "kibana_url (KIBANA_URL)",
+	CloudID:      "cloud_id (CLOUD_ID)",
+	CloudAuth:    "cloud_auth (CLOUD_AUTH)",
+	APIKey:       "api_key (KIBANA_API_KEY)",
+	BearerToken:  "bearer_token (KIBANA_BEARER_TOKEN)",
+	RetryWaitMin: "retry_wait_min (KIBANA_RETRY_WAIT_MIN)",
+	RetryWaitMax: "retry_wait_max (KIBANA_RETRY_WAIT_MAX)",
+}
+
+// providerMeta is the provider configuration passed to resources and data sources
+type providerMeta struct {
+	client *api.Client
+	// secretDetection tells how credentials with literal values in pipeline definitions are reported
+	secretDetection string
+}
+
 // Provider is used by terraform to instantiate Provider object
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"cloud_auth": {
 				Type:        schema.TypeString,
-				Description: "Your CLOUD_AUTH credentials",
-				Required:    true,
+				Description: "Your CLOUD_AUTH credentials (username:password)",
+				Optional:    true,
 				Sensitive:   true,
-				DefaultFunc: schema.EnvDefaultFunc("CLOUD_AUTH", nil),
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvCloudAuth, nil),
+			},
+			"api_key": {
+				Type:        schema.TypeString,
+				Description: "Elasticsearch API key, either as id:api_key or base64 encoded",
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvAPIKey, nil),
+			},
+			"bearer_token": {
+				Type:        schema.TypeString,
+				Description: "Bearer token, e.g. an Elasticsearch service account token",
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvBearerToken, nil),
 			},
 			"kibana_url": {
 				Type:        schema.TypeString,
-				Description: "Kibana URL",
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("KIBANA_URL", nil),
+				Description: "Kibana URL, derived from cloud_id when not specified",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvKibanaURL, nil),
+			},
+			"cloud_id": {
+				Type:        schema.TypeString,
+				Description: "Elastic Cloud deployment ID, used to derive the Kibana URL",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvCloudID, nil),
+			},
+			"space_id": {
+				Type:        schema.TypeString,
+				Description: "Kibana space used by default by resources and data sources",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvSpaceID, nil),
+			},
+			"ca_file": {
+				Type:          schema.TypeString,
+				Description:   "Path of a PEM encoded CA bundle used to verify Kibana certificate",
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc(api.EnvCAFile, nil),
+				ConflictsWith: []string{"ca_pem"},
+			},
+			"ca_pem": {
+				Type:          schema.TypeString,
+				Description:   "PEM encoded CA bundle used to verify Kibana certificate",
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc(api.EnvCAPEM, nil),
+				ConflictsWith: []string{"ca_file"},
+			},
+			"client_cert": {
+				Type:         schema.TypeString,
+				Description:  "Client certificate (PEM content or file path) used for mutual TLS",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc(api.EnvClientCert, nil),
+				RequiredWith: []string{"client_key"},
+			},
+			"client_key": {
+				Type:         schema.TypeString,
+				Description:  "Client private key (PEM content or file path) used for mutual TLS",
+				Optional:     true,
+				Sensitive:    true,
+				DefaultFunc:  schema.EnvDefaultFunc(api.EnvClientKey, nil),
+				RequiredWith: []string{"client_cert"},
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Description: "Disable the verification of Kibana certificate",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(api.EnvInsecureSkipVerify, false),
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Description:  "Number of times a request is retried after a transient failure (429, 5xx, network error)",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc(api.EnvMaxRetries, api.DefaultMaxRetries),
+				ValidateFunc: utils.IntAtLeast(0),
+			},
+			"retry_wait_min": {
+				Type:         schema.TypeInt,
+				Description:  "Minimum time to wait between two retries, in seconds",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc(api.EnvRetryWaitMin, int(api.DefaultRetryWaitMin/time.Second)),
+				ValidateFunc: utils.IntAtLeast(0),
+			},
+			"retry_wait_max": {
+				Type:         schema.TypeInt,
+				Description:  "Maximum time to wait between two retries, in seconds (also caps the Retry-After sent by Kibana)",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc(api.EnvRetryWaitMax, int(api.DefaultRetryWaitMax/time.Second)),
+				ValidateFunc: utils.IntAtLeast(0),
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Description:  "Maximum duration of every request sent to Kibana, in seconds (0 for no limit, operations are then only bound by the resource timeouts)",
+				Optional:     true,
+				Default:      60,
+				ValidateFunc: utils.IntAtLeast(0),
+			},
+			"secret_detection": {
+				Type:         schema.TypeString,
+				Description:  "How credentials with literal values in pipeline definitions are reported: off, warn or error",
+				Optional:     true,
+				Default:      secretDetectionWarn,
+				ValidateFunc: utils.StringInSlice([]string{secretDetectionOff, secretDetectionWarn, secretDetectionError}, false),
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"elastic_logstash_pipeline": resourceLogstashPipeline(),
+			"elastic_logstash_pipeline":     resourceLogstashPipeline(),
+			"elastic_logstash_pipeline_set": resourceLogstashPipelineSet(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"elastic_logstash_pipeline": dataSourceLogstashPipeline(),
+			"elastic_logstash_pipeline":          dataSourceLogstashPipeline(),
+			"elastic_logstash_pipelines":         dataSourceLogstashPipelines(),
+			"elastic_logstash_pipeline_config":   dataSourceLogstashPipelineConfig(),
+			"elastic_logstash_pipeline_template": dataSourceLogstashPipelineTemplate(),
+			"elastic_logstash_pipeline_graph":    dataSourceLogstashPipelineGraph(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	cloudAuth := d.Get("cloud_auth").(string)
-	kibanaURL := d.Get("kibana_url").(string)
-
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	if (cloudAuth != "") && (kibanaURL != "") {
-		c := api.NewClient(cloudAuth, kibanaURL)
-		return c, diags
+	conn := connection(d)
+	kibanaURL, err := conn.URL()
+	if err != nil {
+		if conn.KibanaURL == "" && conn.CloudID != "" {
+			return nil, append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid cloud_id",
+				Detail:        err.Error(),
+				AttributePath: cty.Path{cty.GetAttrStep{Name: "cloud_id"}},
+			})
+		}
+		return nil, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Unable to create Logstash client",
+			Detail:   err.Error(),
+		})
 	}
 
-	diags = append(diags, diag.Diagnostic{
-		Severity: diag.Error,
-		Summary:  "Unable to create Logstash client",
-		Detail:   "KIBANA_URL or CLOUD_AUTH are not specified",
-	})
+	auth, err := conn.Authenticator()
+	if err != nil {
+		return nil, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Unable to create Logstash client",
+			Detail:   err.Error(),
+		})
+	}
 
-	return nil, diags
+	tlsConfig, err := conn.TLS.Build()
+	if err != nil {
+		return nil, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid TLS configuration",
+			Detail:   err.Error(),
+		})
+	}
+
+	if err := conn.ValidateRetry(); err != nil {
+		return nil, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid retry configuration",
+			Detail:   err.Error(),
+		})
+	}
+
+	c := api.NewClientWithAuth(auth, kibanaURL)
+	if tlsConfig != nil {
+		c.SetTLSConfig(tlsConfig)
+	}
+	c.SpaceID = conn.SpaceID
+	c.SetRetryConfig(*conn.Retry)
+	c.SetRequestTimeout(time.Duration(d.Get("request_timeout").(int)) * time.Second)
+	return &providerMeta{client: c, secretDetection: d.Get("secret_detection").(string)}, diags
+}
+
+// connection returns the Kibana connection settings of the provider configuration
+func connection(d *schema.ResourceData) api.Connection {
+	return api.Connection{
+		KibanaURL:   d.Get("kibana_url").(string),
+		CloudID:     d.Get("cloud_id").(string),
+		CloudAuth:   d.Get("cloud_auth").(string),
+		APIKey:      d.Get("api_key").(string),
+		BearerToken: d.Get("bearer_token").(string),
+		SpaceID:     d.Get("space_id").(string),
+		TLS: api.TLSConfig{
+			CAFile:             d.Get("ca_file").(string),
+			CAPEM:              d.Get("ca_pem").(string),
+			ClientCert:         d.Get("client_cert").(string),
+			ClientKey:          d.Get("client_key").(string),
+			InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		},
+		Retry: &api.RetryConfig{
+			MaxRetries: d.Get("max_retries").(int),
+			WaitMin:    time.Duration(d.Get("retry_wait_min").(int)) * time.Second,
+			WaitMax:    time.Duration(d.Get("retry_wait_max").(int)) * time.Second,
+		},
+		Names: providerConnectionNames,
+	}
 }
@@ -1,8 +1,11 @@
 package elastic
 
 import (
+	"context"
+	"encoding/base64"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -35,3 +38,92 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("KIBANA_URL must be set for acceptance tests")
 	}
 }
+
+func TestProviderConfigure_credentials(t *testing.T) {
+	tests := []struct {
+		config        map[string]interface{}
+		expectedError string
+	}{
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "cloud_auth": "elastic:changeme"}, ""},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "api_key": "id:key"}, ""},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601"}, "none of cloud_auth (CLOUD_AUTH), api_key (KIBANA_API_KEY) or bearer_token (KIBANA_BEARER_TOKEN) are set"},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "cloud_auth": "elastic:changeme", "api_key": "id:key"}, "cloud_auth (CLOUD_AUTH), api_key (KIBANA_API_KEY) are mutually exclusive, only one of them must be set"},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "bearer_token": "token"}, ""},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "api_key": "id:key", "bearer_token": "token"}, "api_key (KIBANA_API_KEY), bearer_token (KIBANA_BEARER_TOKEN) are mutually exclusive, only one of them must be set"},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "cloud_auth": "elastic"}, "invalid cloud_auth (CLOUD_AUTH), expected username:password"},
+		{map[string]interface{}{"kibana_url": "https://localhost:5601", "cloud_auth": "elastic:changeme", "insecure_skip_verify": true}, ""},
+		{map[string]interface{}{"kibana_url": "https://localhost:5601", "cloud_auth": "elastic:changeme", "ca_pem": "invalid"}, "no valid PEM encoded certificate found in CA"},
+		{map[string]interface{}{"cloud_id": "staging:" + base64.StdEncoding.EncodeToString([]byte("cloud.es.io$es1$kb2")), "cloud_auth": "elastic:changeme"}, ""},
+		{map[string]interface{}{"cloud_id": "staging:" + base64.StdEncoding.EncodeToString([]byte("cloud.es.io$es1")), "cloud_auth": "elastic:changeme"}, `cloud_id "staging" does not contain any Kibana endpoint`},
+		{map[string]interface{}{"cloud_id": "staging", "cloud_auth": "elastic:changeme"}, `invalid cloud_id ("staging"): expected name:base64_encoded_endpoints`},
+		{map[string]interface{}{"cloud_auth": "elastic:changeme"}, "neither kibana_url (KIBANA_URL) nor cloud_id (CLOUD_ID) are set"},
+		{map[string]interface{}{"kibana_url": "http://localhost:5601", "cloud_auth": "elastic:changeme", "retry_wait_min": 10, "retry_wait_max": 5}, "retry_wait_min (KIBANA_RETRY_WAIT_MIN) cannot be greater than retry_wait_max (KIBANA_RETRY_WAIT_MAX), got 10s and 5s"},
+	}
+
+	// Credentials and URL come from the config only
+	for _, env := range []string{"CLOUD_AUTH", "KIBANA_API_KEY", "KIBANA_BEARER_TOKEN", "KIBANA_URL", "CLOUD_ID", "KIBANA_CA_PEM", "KIBANA_INSECURE_SKIP_VERIFY", "KIBANA_RETRY_WAIT_MIN", "KIBANA_RETRY_WAIT_MAX"} {
+		if v, ok := os.LookupEnv(env); ok {
+			os.Unsetenv(env)
+			defer os.Setenv(env, v)
+		}
+	}
+
+	for _, test := range tests {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, test.config)
+		_, diags := providerConfigure(context.Background(), d)
+		if test.expectedError == "" {
+			if diags.HasError() {
+				t.Fatalf("expected no error for %v, got %v", test.config, diags)
+			}
+			continue
+		}
+		if !diags.HasError() || diags[0].Detail != test.expectedError {
+			t.Fatalf("expected error %q for %v, got %v", test.expectedError, test.config, diags)
+		}
+	}
+}
+
+func TestProviderConfigure_requestTimeout(t *testing.T) {
+	tests := []struct {
+		config   map[string]interface{}
+		expected time.Duration
+	}{
+		{map[string]interface{}{}, time.Minute},
+		{map[string]interface{}{"request_timeout": 300}, 5 * time.Minute},
+		{map[string]interface{}{"request_timeout": 0}, 0},
+	}
+
+	for _, test := range tests {
+		test.config["kibana_url"] = "http://localhost:5601"
+		test.config["cloud_auth"] = "elastic:changeme"
+		d := schema.TestResourceDataRaw(t, Provider().Schema, test.config)
+		meta, diags := providerConfigure(context.Background(), d)
+		if diags.HasError() {
+			t.Fatalf("expected no error for %v, got %v", test.config, diags)
+		}
+		if timeout := meta.(*providerMeta).client.HTTPClient.Timeout; timeout != test.expected {
+			t.Fatalf("expected request timeout %s for %v, got %s", test.expected, test.config, timeout)
+		}
+	}
+}
+
+func TestProviderConfigure_retryEnv(t *testing.T) {
+	for env, value := range map[string]string{"KIBANA_MAX_RETRIES": "5", "KIBANA_RETRY_WAIT_MIN": "2", "KIBANA_RETRY_WAIT_MAX": "10"} {
+		if v, ok := os.LookupEnv(env); ok {
+			defer os.Setenv(env, v)
+		} else {
+			defer os.Unsetenv(env)
+		}
+		os.Setenv(env, value)
+	}
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{"kibana_url": "http://localhost:5601", "cloud_auth": "elastic:changeme", "retry_wait_max": 20})
+	meta, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	c := meta.(*providerMeta).client
+	if c.MaxRetries != 5 || c.RetryWaitMin != 2*time.Second || c.RetryWaitMax != 20*time.Second {
+		t.Fatalf("expected 5 retries waiting between 2s and 20s, got %d retries waiting between %s and %s", c.MaxRetries, c.RetryWaitMin, c.RetryWaitMax)
+	}
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
 	"github.com/skysoft-atm/terraform-provider-elastic/utils"
 )
 
+// defaultLogstashPipelineTimeout bounds every operation (retries included) unless a timeouts block is set
+const defaultLogstashPipelineTimeout = 5 * time.Minute
+
 func resourceLogstashPipeline() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
@@ -20,12 +28,28 @@ func resourceLogstashPipeline() *schema.Resource {
 				Required:    true,
 				Description: `Pipeline name, must be unique.`,
 			},
+			"space_id": {
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Computed:    true,
+				Description: `Kibana space of the pipeline, defaults to the provider space_id.`,
+			},
 			"pipeline": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validateLogstashPipeline,
+				DiffSuppressFunc: suppressEquivalentPipeline,
 				Description: `Pipeline definition which will be used by logstash instances.
 				Should be composed by 3 sections (input, filter and output).`,
 			},
+			"semantic_diff": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+				Description: `Only report semantic changes of the pipeline definition: whitespaces,
+				comments and quoting style changes are ignored.`,
+			},
 			"description": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -36,64 +60,41 @@ func resourceLogstashPipeline() *schema.Resource {
 				Computed:    true,
 				Description: `Token owner used for the pipeline creation.`,
 			},
+			"last_modified": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Date of the last modification.`,
+			},
+			"applied_last_modified": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Date of the last modification applied (or acknowledged) by Terraform.`,
+			},
+			"applied_username": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `User of the last modification applied (or acknowledged) by Terraform.`,
+			},
+			"force_overwrite": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: `Update the pipeline even if it was modified outside of Terraform
+				(last_modified or username changed in Kibana) since Terraform last applied it.`,
+			},
+			"pipeline_inputs_addresses": pipelineInputsAddressesSchema(),
+			"pipeline_output_addresses": pipelineOutputAddressesSchema(),
 			"settings": {
 				Type:     schema.TypeList,
 				MaxItems: 1,
-				Required: true, // Workaround, it sounds like the current Kibana API does not behave has expected:
-				// If settings is empty, then the API returns null value even if default values are
-				// applied...
+				Optional: true,
+				// Logstash default values when not specified, they are explicitly sent since
+				// Kibana returns null settings otherwise
+				Computed: true,
+				Description: `Pipeline settings, removing the block keeps the current settings
+				(settings {} resets them to their default values).`,
 				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"batch_delay": {
-							Type:         schema.TypeInt,
-							Optional:     true,
-							Default:      50,
-							ValidateFunc: utils.IntAtLeast(1),
-							Description: `This setting adjusts the latency of the Logstash pipeline. 
-							Pipeline batch delay is the maximum amount of time in milliseconds that 
-							Logstash waits for new messages after receiving an event in the current 
-							pipeline worker thread.`,
-						},
-						"batch_size": {
-							Type:         schema.TypeInt,
-							Default:      125,
-							Optional:     true,
-							ValidateFunc: utils.IntAtLeast(1),
-							Description: `This setting defines the maximum number of events an 
-							individual worker thread collects before attempting to execute filters 
-							and outputs. Larger batch sizes are generally more efficient, but 
-							increase memory overhead.`,
-						},
-						"workers": {
-							Type:         schema.TypeInt,
-							Optional:     true,
-							Default:      1,
-							ValidateFunc: utils.IntAtLeast(1),
-							Description: `This setting determines how many threads to run for filter
-							 and output processing.`,
-						},
-						"queue_checkpoint_writes": {
-							Type:         schema.TypeInt,
-							Optional:     true,
-							Default:      1024,
-							ValidateFunc: utils.IntAtLeast(1),
-							Description: `This setting specifies the maximum number of events that
-							 may be written to disk before forcing a checkpoint. `,
-						},
-						"queue_max_bytes": {
-							Type:        schema.TypeString,
-							Optional:    true,
-							Default:     "1gb",
-							Description: `The total capacity of the queue in number of bytes.`,
-						},
-						"queue_type": {
-							Type:         schema.TypeString,
-							Optional:     true,
-							Default:      "memory",
-							Description:  `Specify persisted to enable persistent queues.`,
-							ValidateFunc: utils.StringInSlice([]string{"memory", "persisted"}, false),
-						},
-					},
+					Schema: resourceLogstashPipelineSettingsSchema(),
 				},
 			},
 		},
@@ -101,11 +102,26 @@ func resourceLogstashPipeline() *schema.Resource {
 		ReadContext:   resourceLogstashPipelineRead,
 		UpdateContext: resourceLogstashPipelineUpdate,
 		DeleteContext: resourceLogstashPipelineDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Read:   schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Update: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Delete: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceLogstashPipelineImport,
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffPipelineSecrets,
+			customizeDiffQueueCapacity,
+			customizeDiffPipelineAddresses,
+			customizeDiffPipelineModification,
+		),
 	}
 }
 
 func resourceLogstashPipelineCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*api.Client)
+	c := m.(*providerMeta).client
 
 	// Warning on errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -115,84 +131,121 @@ func resourceLogstashPipelineCreate(ctx context.Context, d *schema.ResourceData,
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = c.CreateOrUpdateLogstashPipeline(ctx, &data)
+	spaceID := pipelineSpace(d, c)
+	err = c.WithSpace(spaceID).CreateOrUpdateLogstashPipeline(ctx, &data)
 	if err != nil {
 		log.Printf("Error : %s", err.Error())
-		return diag.FromErr(err)
+		return logstashPipelineWriteDiagnostics(err, spaceID, data.ID)
 	}
 
-	d.SetId(data.ID)
+	d.SetId(buildPipelineID(spaceID, data.ID))
 
-	resourceLogstashPipelineRead(ctx, d, m)
-
-	return diags
+	return append(diags, readAppliedLogstashPipeline(ctx, d, m)...)
 }
 
 func resourceLogstashPipelineRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*api.Client)
+	c := m.(*providerMeta).client
 
 	// Warning on errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	pipelineID := d.Id()
-	// API crashes if the pipeline_id is not known
-	// Let's first look if we can find it in a list
-	pipes, err := c.GetLogstashPipelines(ctx)
+	spaceID, pipelineID := parsePipelineID(d.Id())
+	c = c.WithSpace(spaceID)
+	pipeline, summary, err := c.FindLogstashPipeline(ctx, pipelineID)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-
-	found := false
-	for _, p := range pipes.Pipelines {
-		if pipelineID == p.ID {
-			found = true
-			break
-		}
+	if pipeline == nil {
+		// Removed outside of Terraform, it will be planned for creation again
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Logstash pipeline not found",
+			Detail:   fmt.Sprintf("Pipeline %q no longer exists in Kibana space %q, removing it from state", pipelineID, spaceID),
+		})
+		d.SetId("")
+		return diags
 	}
 
-	if found {
-		pipeline, err := c.GetLogstashPipeline(ctx, pipelineID)
-		if err != nil {
+	pl := flattenLogstashPipelineData(pipeline, summary)
+	for key, value := range pl {
+		if err := d.Set(key, value); err != nil {
 			return diag.FromErr(err)
 		}
-
-		pl := flattenLogstashPipelineData(pipeline)
-		for key, value := range pl {
-			if err := d.Set(key, value); err != nil {
-				diag.FromErr(err)
-			}
-		}
+	}
+	if err := d.Set("space_id", spaceID); err != nil {
+		return diag.FromErr(err)
 	}
 
-	return diags
+	return append(diags, pipelineSecretsDiagnostics(pipeline.Configuration.Pipeline, m.(*providerMeta).secretDetection, cty.GetAttrPath("pipeline"))...)
 }
 
 func resourceLogstashPipelineUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*api.Client)
+	c := m.(*providerMeta).client
 
-	if d.HasChange("description") || d.HasChange("pipeline") || d.HasChange("settings") || d.HasChange("username") {
+	if d.HasChange("description") || d.HasChange("pipeline") || d.HasChange("settings") {
 		data, err := pipelineLogstashData(d)
 		if err != nil {
 			return diag.FromErr(err)
 		}
+		spaceID, _ := parsePipelineID(d.Id())
+		c = c.WithSpace(spaceID)
+		if !d.Get("force_overwrite").(bool) {
+			if diags := checkLogstashPipelineNotModified(ctx, d, c, data.ID); diags.HasError() {
+				return diags
+			}
+		}
 		err = c.CreateOrUpdateLogstashPipeline(ctx, &data)
 		if err != nil {
-			return diag.FromErr(err)
+			return logstashPipelineWriteDiagnostics(err, spaceID, data.ID)
 		}
+		return readAppliedLogstashPipeline(ctx, d, m)
 	}
+	// Remote modifications acknowledged by the plan, applied_last_modified and applied_username are stored as planned
 	return resourceLogstashPipelineRead(ctx, d, m)
 }
 
+// logstashPipelineWriteDiagnostics reports a failed write of the pipeline, Kibana answering 409 when the pipeline
+// was written by someone else at the same time
+func logstashPipelineWriteDiagnostics(err error, spaceID, pipelineID string) diag.Diagnostics {
+	if !api.IsConflict(err) {
+		return diag.FromErr(err)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Logstash pipeline modified concurrently",
+		Detail: fmt.Sprintf("Kibana rejected the write of pipeline %q in space %q since it was modified at the same time, "+
+			"refresh and apply again to review the changes: %s", pipelineID, spaceID, err),
+		AttributePath: cty.GetAttrPath("pipeline"),
+	}}
+}
+
+// readAppliedLogstashPipeline reads the pipeline Terraform just wrote, and records its modification date and user
+// to detect the modifications made outside of Terraform afterwards
+func readAppliedLogstashPipeline(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	diags := resourceLogstashPipelineRead(ctx, d, m)
+	if diags.HasError() || len(d.Id()) == 0 {
+		return diags
+	}
+	if err := d.Set("applied_last_modified", d.Get("last_modified")); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if err := d.Set("applied_username", d.Get("username")); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
 func resourceLogstashPipelineDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*api.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	pipelineID := d.Id()
+	spaceID, pipelineID := parsePipelineID(d.Id())
 
-	err := c.DeleteLogstashPipeline(ctx, pipelineID)
-	if err != nil {
+	err := c.WithSpace(spaceID).DeleteLogstashPipeline(ctx, pipelineID)
+	// Already deleted, nothing to do
+	if err != nil && !api.IsNotFound(err) {
 		return diag.FromErr(err)
 	}
 	// d.SetId("") is automatically called assuming delete returns no errors, but
@@ -202,6 +255,79 @@ func resourceLogstashPipelineDelete(ctx context.Context, d *schema.ResourceData,
 	return diags
 }
 
+// resourceLogstashPipelineImport accepts either pipeline_id (provider space) or space_id/pipeline_id,
+// the pipeline itself is then loaded by the read function
+func resourceLogstashPipelineImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c := m.(*providerMeta).client
+
+	spaceID, pipelineID := parsePipelineID(d.Id())
+	if !strings.Contains(d.Id(), "/") && len(c.SpaceID) > 0 {
+		spaceID = c.SpaceID
+	}
+	if len(spaceID) == 0 || len(pipelineID) == 0 {
+		return nil, fmt.Errorf("Unexpected ID format (%q). Expected pipeline_id or space_id/pipeline_id", d.Id())
+	}
+
+	d.SetId(buildPipelineID(spaceID, pipelineID))
+	if err := d.Set("pipeline_id", pipelineID); err != nil {
+		return nil, err
+	}
+	if err := d.Set("space_id", spaceID); err != nil {
+		return nil, err
+	}
+	// Not known by Kibana, set to their default values
+	if err := d.Set("semantic_diff", true); err != nil {
+		return nil, err
+	}
+	if err := d.Set("force_overwrite", false); err != nil {
+		return nil, err
+	}
+	// The imported pipeline is the reference to detect modifications made outside of Terraform
+	summary, err := c.WithSpace(spaceID).GetLogstashPipelineSummary(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	if summary != nil {
+		if err := d.Set("applied_last_modified", summary.LastModified); err != nil {
+			return nil, err
+		}
+		if err := d.Set("applied_username", summary.Username); err != nil {
+			return nil, err
+		}
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// pipelineSpace returns the Kibana space of the pipeline: the one set on the resource,
+// otherwise the provider one, otherwise the default space
+func pipelineSpace(d *schema.ResourceData, c *api.Client) string {
+	if v, ok := d.GetOk("space_id"); ok {
+		return v.(string)
+	}
+	if len(c.SpaceID) > 0 {
+		return c.SpaceID
+	}
+	return api.DefaultSpaceID
+}
+
+// buildPipelineID returns the resource ID: pipeline_id for the default space, space_id/pipeline_id otherwise.
+// Pipeline IDs cannot contain a '/', so the ID is never ambiguous.
+func buildPipelineID(spaceID, pipelineID string) string {
+	if len(spaceID) == 0 || spaceID == api.DefaultSpaceID {
+		return pipelineID
+	}
+	return spaceID + "/" + pipelineID
+}
+
+// parsePipelineID is the reverse operation of buildPipelineID
+func parsePipelineID(id string) (string, string) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 {
+		return api.DefaultSpaceID, id
+	}
+	return parts[0], parts[1]
+}
+
 func pipelineLogstashData(d *schema.ResourceData) (api.LogstashPipeline, error) {
 	data := api.LogstashPipeline{}
 
@@ -223,19 +349,230 @@ func pipelineLogstashData(d *schema.ResourceData) (api.LogstashPipeline, error)
 	if v, ok := d.GetOk("description"); ok {
 		config.Description = v.(string)
 	}
-	var settings api.Settings
-	v := d.Get("settings").([]interface{})
-	for _, item := range v {
-		i := item.(map[string]interface{})
-		settings.PipelineBatchDelay = i["batch_delay"].(int)
-		settings.PipelineWorkers = i["workers"].(int)
-		settings.PipelineBatchSize = i["batch_size"].(int)
-		settings.QueueCheckpointWrites = i["queue_checkpoint_writes"].(int)
-		settings.QueueMaxBytes = i["queue_max_bytes"].(string)
-		settings.QueueType = i["queue_type"].(string)
-	}
-	config.Settings = &settings
+	config.Settings = expandSettings(d.Get("settings").([]interface{}))
 
 	data.Configuration = &config
 	return data, nil
 }
+
+// validateLogstashPipeline checks the pipeline definition syntax at plan time.
+// Credentials with literal values are reported by read according to secret_detection, which validation has no access to.
+func validateLogstashPipeline(v interface{}, path cty.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	definition, ok := v.(string)
+	if !ok {
+		return append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Invalid pipeline definition",
+			Detail:        "expected pipeline to be a string",
+			AttributePath: path,
+		})
+	}
+	if len(definition) == 0 {
+		return diags
+	}
+
+	if _, err := logstash.Parse(definition); err != nil {
+		return append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Invalid pipeline definition",
+			Detail:        err.Error(),
+			AttributePath: path,
+		})
+	}
+	return diags
+}
+
+// suppressEquivalentPipeline ignores pipeline changes which are not semantic (unless semantic_diff is disabled)
+func suppressEquivalentPipeline(k, old, new string, d *schema.ResourceData) bool {
+	if !d.Get("semantic_diff").(bool) {
+		return false
+	}
+	return logstash.Equivalent(old, new)
+}
+
+// customizeDiffPipelineSecrets rejects new or changed pipeline definitions holding credentials
+// with literal values when secret_detection is error, they are otherwise reported as warnings by read
+func customizeDiffPipelineSecrets(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	meta, ok := m.(*providerMeta)
+	if !ok || meta.secretDetection != secretDetectionError {
+		return nil
+	}
+	if !d.HasChange("pipeline") || !d.NewValueKnown("pipeline") {
+		return nil
+	}
+
+	secrets := pipelineSecrets(d.Get("pipeline").(string))
+	if len(secrets) == 0 {
+		return nil
+	}
+	details := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		details = append(details, secret.String())
+	}
+	return fmt.Errorf("pipeline contains credentials with literal values (secret_detection = %q):\n%s", meta.secretDetection, strings.Join(details, "\n"))
+}
+
+// pipelineSecrets returns the credentials with literal values of the pipeline definition, none if it does not parse
+func pipelineSecrets(definition string) []logstash.Secret {
+	config, err := logstash.Parse(definition)
+	if err != nil {
+		return nil
+	}
+	return logstash.FindSecrets(config)
+}
+
+// pipelineSecretsDiagnostics warns about credentials with literal values, which are stored in Kibana and in the state
+func pipelineSecretsDiagnostics(definition string, mode string, path cty.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if mode == secretDetectionOff {
+		return diags
+	}
+	for _, secret := range pipelineSecrets(definition) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "Plaintext secret in pipeline definition",
+			Detail:        secret.String(),
+			AttributePath: path,
+		})
+	}
+	return diags
+}
+
+func pipelineInputsAddressesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: `Addresses listened to by the pipeline inputs of the definition (pipeline-to-pipeline communication).`,
+	}
+}
+
+func pipelineOutputAddressesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: `Addresses the pipeline outputs of the definition send events to (pipeline-to-pipeline communication).`,
+	}
+}
+
+// pipelineAddresses returns the pipeline-to-pipeline input and output addresses of the definition, none if it does not parse
+func pipelineAddresses(definition string) (inputs []interface{}, outputs []interface{}) {
+	inputs, outputs = []interface{}{}, []interface{}{}
+	config, err := logstash.Parse(definition)
+	if err != nil {
+		return inputs, outputs
+	}
+	inputAddresses, outputAddresses := logstash.PipelineAddresses(config)
+	for _, address := range inputAddresses {
+		inputs = append(inputs, address)
+	}
+	for _, address := range outputAddresses {
+		outputs = append(outputs, address)
+	}
+	return inputs, outputs
+}
+
+// customizeDiffPipelineAddresses plans the addresses of the new pipeline definition
+func customizeDiffPipelineAddresses(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.HasChange("pipeline") {
+		return nil
+	}
+	if !d.NewValueKnown("pipeline") {
+		if err := d.SetNewComputed("pipeline_inputs_addresses"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("pipeline_output_addresses")
+	}
+	inputs, outputs := pipelineAddresses(d.Get("pipeline").(string))
+	if err := d.SetNew("pipeline_inputs_addresses", inputs); err != nil {
+		return err
+	}
+	return d.SetNew("pipeline_output_addresses", outputs)
+}
+
+// checkLogstashPipelineNotModified fails if the pipeline was modified in Kibana since Terraform last applied it,
+// showing the changes of the remote definition
+func checkLogstashPipelineNotModified(ctx context.Context, d *schema.ResourceData, c *api.Client, id string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Unlike last_modified and username, not refreshed by read (and planned as unknown)
+	lastModified, _ := d.GetChange("applied_last_modified")
+	username, _ := d.GetChange("applied_username")
+	if len(lastModified.(string)) == 0 {
+		// State written by a previous version of the provider, nothing to compare with
+		return diags
+	}
+
+	summary, err := c.GetLogstashPipelineSummary(ctx, id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if summary == nil || (summary.LastModified == lastModified.(string) && summary.Username == username.(string)) {
+		// Deleted outside of Terraform in between, it is simply created again
+		return diags
+	}
+
+	remote, err := c.GetLogstashPipeline(ctx, id)
+	if api.IsNotFound(err) {
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// The definition in the prior state, the one Terraform last applied unless refreshed since
+	applied, _ := d.GetChange("pipeline")
+	changes := utils.LineDiff(applied.(string), remote.Configuration.Pipeline)
+	if len(changes) == 0 {
+		changes = "(no change of the definition since the state was refreshed, the description or the settings may have changed)\n"
+	}
+
+	return append(diags, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  "Logstash pipeline modified outside of Terraform",
+		Detail: fmt.Sprintf("Pipeline %q was modified by %q at %s, after Terraform last applied it (modified by %q at %s). "+
+			"Report the changes in the configuration to keep them (the modification is then acknowledged by the next apply), "+
+			"or set force_overwrite = true to overwrite them.\n\n"+
+			"Remote definition (- state, + Kibana):\n%s",
+			id, summary.Username, summary.LastModified, username, lastModified, changes),
+	})
+}
+
+// customizeDiffPipelineModification plans the modification date and user of updated pipelines. When no update is
+// planned, the modifications made outside of Terraform are acknowledged: the configuration matches them.
+func customizeDiffPipelineModification(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if len(d.Id()) == 0 {
+		return nil
+	}
+	// HasChange ignores diff suppression (e.g. equivalent definitions), only the planned changes count
+	updated := false
+	for _, key := range d.GetChangedKeysPrefix("") {
+		for _, attribute := range []string{"description", "pipeline", "settings"} {
+			if key == attribute || strings.HasPrefix(key, attribute+".") {
+				updated = true
+			}
+		}
+	}
+	if !updated {
+		appliedLastModified := d.Get("applied_last_modified").(string)
+		if len(appliedLastModified) == 0 {
+			// Nothing to acknowledge for the states of previous versions of the provider
+			return nil
+		}
+		if appliedLastModified == d.Get("last_modified").(string) && d.Get("applied_username") == d.Get("username") {
+			return nil
+		}
+		if err := d.SetNew("applied_last_modified", d.Get("last_modified")); err != nil {
+			return err
+		}
+		return d.SetNew("applied_username", d.Get("username"))
+	}
+	for _, key := range []string{"last_modified", "username", "applied_last_modified", "applied_username"} {
+		if err := d.SetNewComputed(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/skysoft-atm/terraform-provider-elastic/logstash"
+	"github.com/skysoft-atm/terraform-provider-elastic/utils"
+)
+
+func resourceLogstashPipelineSet() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"space_id": {
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Computed:    true,
+				Description: `Kibana space of the pipelines, defaults to the provider space_id.`,
+			},
+			"pipelines": {
+				Type:             schema.TypeMap,
+				Required:         true,
+				Elem:             &schema.Schema{Type: schema.TypeString},
+				DiffSuppressFunc: suppressEquivalentPipelineSetMember,
+				Description: `Pipeline definitions by pipeline ID, created without description and with the Logstash
+				default settings. Only semantic changes of the definitions are reported, descriptions and settings
+				changed outside of Terraform are reported as warnings and restored when the definition changes.`,
+			},
+			"prune_prefix": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"prune_prefix", "prune_regex"},
+				ValidateFunc: utils.StringIsNotEmpty,
+				Description:  `Pipelines whose ID starts with this prefix are deleted if not in pipelines.`,
+			},
+			"prune_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: utils.All(utils.StringIsNotEmpty, utils.IsValidRegExp),
+				Description:  `Pipelines whose ID matches this regular expression are deleted if not in pipelines.`,
+			},
+			"dry_run": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: `Only report the pipelines which would be pruned (as warnings and in prune_ids)
+				instead of deleting them.`,
+			},
+			"prune_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Description: `IDs of the pipelines matching prune_prefix or prune_regex which are not in pipelines,
+				listed when planning and deleted on apply unless dry_run is set (only the planned ones are deleted).`,
+			},
+		},
+		CreateContext: resourceLogstashPipelineSetCreate,
+		ReadContext:   resourceLogstashPipelineSetRead,
+		UpdateContext: resourceLogstashPipelineSetUpdate,
+		DeleteContext: resourceLogstashPipelineSetDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Read:   schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Update: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+			Delete: schema.DefaultTimeout(defaultLogstashPipelineTimeout),
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffPipelineSetDefinitions,
+			customizeDiffPipelineSetPrune,
+		),
+	}
+}
+
+func resourceLogstashPipelineSetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	spaceID := pipelineSpace(d, c)
+	if err := d.Set("space_id", spaceID); err != nil {
+		return diag.FromErr(err)
+	}
+	pruneIDs := d.Get("prune_ids").([]interface{})
+	if err := applyLogstashPipelineSet(ctx, d, c.WithSpace(spaceID), map[string]interface{}{}, pruneIDs); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(buildPipelineSetID(spaceID, d))
+
+	return readAppliedLogstashPipelineSet(ctx, d, m, pruneIDs)
+}
+
+func resourceLogstashPipelineSetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	spaceID := d.Get("space_id").(string)
+	c = c.WithSpace(spaceID)
+
+	scope, err := pipelineSetScope(d.Get("prune_prefix").(string), d.Get("prune_regex").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	managed := d.Get("pipelines").(map[string]interface{})
+	pipelines := make(map[string]interface{})
+	pruneIDs := make([]string, 0)
+	var modified []string
+	for _, p := range pipes.Pipelines {
+		if _, ok := managed[p.ID]; !ok {
+			if scope(p.ID) {
+				pruneIDs = append(pruneIDs, p.ID)
+			}
+			continue
+		}
+
+		pipeline, err := c.GetLogstashPipeline(ctx, p.ID)
+		if api.IsNotFound(err) {
+			// Deleted in between, it will be planned for creation again
+			continue
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		pipelines[p.ID] = pipeline.Configuration.Pipeline
+		path := cty.GetAttrPath("pipelines").IndexString(p.ID)
+		diags = append(diags, pipelineSecretsDiagnostics(pipeline.Configuration.Pipeline, m.(*providerMeta).secretDetection, path)...)
+		if !isPipelineSetMetadata(pipeline) {
+			modified = append(modified, p.ID)
+		}
+	}
+	sort.Strings(pruneIDs)
+	sort.Strings(modified)
+
+	if err := d.Set("pipelines", pipelines); err != nil {
+		return diag.FromErr(err)
+	}
+	// Without dry_run, the pipelines to prune are listed when planning
+	dryRun := d.Get("dry_run").(bool)
+	if !dryRun {
+		pruneIDs = []string{}
+	}
+	if err := d.Set("prune_ids", pruneIDs); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if dryRun && len(pruneIDs) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Logstash pipelines would be pruned",
+			Detail:   fmt.Sprintf("Pipelines %s of Kibana space %q are not managed by this set and would be deleted without dry_run", strings.Join(pruneIDs, ", "), spaceID),
+		})
+	}
+	if len(modified) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Logstash pipelines modified outside of Terraform",
+			Detail: fmt.Sprintf("Pipelines %s of Kibana space %q have a description or settings which are not the ones of the set "+
+				"(no description and the Logstash default settings), they are restored when their definition changes", strings.Join(modified, ", "), spaceID),
+			AttributePath: cty.GetAttrPath("pipelines"),
+		})
+	}
+	return diags
+}
+
+func resourceLogstashPipelineSetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	old, _ := d.GetChange("pipelines")
+	pruneIDs := d.Get("prune_ids").([]interface{})
+	if err := applyLogstashPipelineSet(ctx, d, c.WithSpace(d.Get("space_id").(string)), old.(map[string]interface{}), pruneIDs); err != nil {
+		return diag.FromErr(err)
+	}
+	return readAppliedLogstashPipelineSet(ctx, d, m, pruneIDs)
+}
+
+// readAppliedLogstashPipelineSet reads the set after an apply, keeping the planned prune_ids which read would reset
+func readAppliedLogstashPipelineSet(ctx context.Context, d *schema.ResourceData, m interface{}, pruneIDs []interface{}) diag.Diagnostics {
+	diags := resourceLogstashPipelineSetRead(ctx, d, m)
+	if diags.HasError() || d.Get("dry_run").(bool) {
+		return diags
+	}
+	if err := d.Set("prune_ids", pruneIDs); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+func resourceLogstashPipelineSetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	// Only the pipelines of the set are deleted, not the ones which would be pruned
+	c = c.WithSpace(d.Get("space_id").(string))
+	for id := range d.Get("pipelines").(map[string]interface{}) {
+		if err := c.DeleteLogstashPipeline(ctx, id); err != nil && !api.IsNotFound(err) {
+			return diag.FromErr(err)
+		}
+	}
+	d.SetId("")
+
+	return diags
+}
+
+// applyLogstashPipelineSet creates or updates the pipelines which changed since old, deletes the ones
+// removed from the set, then prunes the planned unmanaged pipelines unless dry_run is set
+func applyLogstashPipelineSet(ctx context.Context, d *schema.ResourceData, c *api.Client, old map[string]interface{}, pruneIDs []interface{}) error {
+	pipelines := d.Get("pipelines").(map[string]interface{})
+	for _, id := range sortedKeys(pipelines) {
+		definition := pipelines[id].(string)
+		if previous, ok := old[id]; ok && previous.(string) == definition {
+			continue
+		}
+		data := api.NewLogstashPipeline(id, "", definition, expandSettings(nil))
+		if err := c.CreateOrUpdateLogstashPipeline(ctx, data); err != nil {
+			return fmt.Errorf("unable to create or update pipeline %q: %w", id, err)
+		}
+	}
+
+	for _, id := range sortedKeys(old) {
+		if _, ok := pipelines[id]; ok {
+			continue
+		}
+		if err := c.DeleteLogstashPipeline(ctx, id); err != nil && !api.IsNotFound(err) {
+			return fmt.Errorf("unable to delete pipeline %q: %w", id, err)
+		}
+	}
+
+	if d.Get("dry_run").(bool) {
+		return nil
+	}
+	// Pipelines created since the plan are left for the next one
+	for _, v := range pruneIDs {
+		id := v.(string)
+		if _, ok := pipelines[id]; ok {
+			continue
+		}
+		if err := c.DeleteLogstashPipeline(ctx, id); err != nil && !api.IsNotFound(err) {
+			return fmt.Errorf("unable to prune pipeline %q: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// isPipelineSetMetadata reports whether the pipeline has the description and settings the set creates its pipelines with
+func isPipelineSetMetadata(pipeline *api.LogstashPipeline) bool {
+	if len(pipeline.Configuration.Description) > 0 {
+		return false
+	}
+	return reflect.DeepEqual(flattenSettings(pipeline.Configuration.Settings), flattenSettings(expandSettings(nil)))
+}
+
+// pipelineSetScope returns the predicate matching the IDs of the pipelines to prune, regex takes precedence over prefix.
+// An empty scope is rejected, it would match every pipeline of the space.
+func pipelineSetScope(prefix, regex string) (func(id string) bool, error) {
+	if len(prefix) == 0 && len(regex) == 0 {
+		return nil, fmt.Errorf("either prune_prefix or prune_regex must be a non empty string")
+	}
+	if len(regex) > 0 {
+		r, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, err
+		}
+		return r.MatchString, nil
+	}
+	return func(id string) bool {
+		return strings.HasPrefix(id, prefix)
+	}, nil
+}
+
+// buildPipelineSetID returns the resource ID: the Kibana space and the prune prefix or regex at creation,
+// the ID is kept when they are updated
+func buildPipelineSetID(spaceID string, d *schema.ResourceData) string {
+	if v, ok := d.GetOk("prune_regex"); ok {
+		return spaceID + "/" + v.(string)
+	}
+	return spaceID + "/" + d.Get("prune_prefix").(string) + "*"
+}
+
+// suppressEquivalentPipelineSetMember ignores pipeline changes which are not semantic
+func suppressEquivalentPipelineSetMember(k, old, new string, d *schema.ResourceData) bool {
+	// Number of pipelines
+	if strings.HasSuffix(k, ".%") {
+		return false
+	}
+	return logstash.Equivalent(old, new)
+}
+
+// customizeDiffPipelineSetDefinitions checks the syntax of the known pipeline definitions,
+// and rejects the ones holding credentials with literal values when secret_detection is error
+func customizeDiffPipelineSetDefinitions(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.HasChange("pipelines") || !d.NewValueKnown("pipelines") {
+		return nil
+	}
+	meta, _ := m.(*providerMeta)
+
+	pipelines := d.Get("pipelines").(map[string]interface{})
+	var errors []string
+	for _, id := range sortedKeys(pipelines) {
+		definition := pipelines[id].(string)
+		if _, err := logstash.Parse(definition); err != nil {
+			errors = append(errors, fmt.Sprintf("pipelines[%q]: invalid pipeline definition: %s", id, err))
+			continue
+		}
+		if meta == nil || meta.secretDetection != secretDetectionError {
+			continue
+		}
+		for _, secret := range pipelineSecrets(definition) {
+			errors = append(errors, fmt.Sprintf("pipelines[%q]: %s", id, secret))
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// customizeDiffPipelineSetPrune lists the unmanaged pipelines to prune, the apply only deletes these ones.
+// Pipelines which are removed from the set are not listed, their deletion shows up in pipelines.
+func customizeDiffPipelineSetPrune(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	for _, key := range []string{"space_id", "pipelines", "prune_prefix", "prune_regex"} {
+		if !d.NewValueKnown(key) {
+			// Nothing is pruned, the next plan will list them
+			return d.SetNewComputed("prune_ids")
+		}
+	}
+
+	meta, ok := m.(*providerMeta)
+	if !ok {
+		return fmt.Errorf("unable to list the pipelines to prune: the provider is not configured")
+	}
+	c := meta.client.WithSpace(d.Get("space_id").(string))
+	scope, err := pipelineSetScope(d.Get("prune_prefix").(string), d.Get("prune_regex").(string))
+	if err != nil {
+		return err
+	}
+	pipes, err := c.GetLogstashPipelines(ctx)
+	if err != nil {
+		return err
+	}
+
+	old, new := d.GetChange("pipelines")
+	pruneIDs := make([]string, 0)
+	for _, p := range pipes.Pipelines {
+		_, managed := new.(map[string]interface{})[p.ID]
+		_, removed := old.(map[string]interface{})[p.ID]
+		if !managed && !removed && scope(p.ID) {
+			pruneIDs = append(pruneIDs, p.ID)
+		}
+	}
+	sort.Strings(pruneIDs)
+	return d.SetNew("prune_ids", pruneIDs)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,256 @@
+package elastic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+)
+
+func TestResourceLogstashPipelineSet(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "team-a-legacy", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+	kibana.put(api.DefaultSpaceID, "team-b-web", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+
+	r := resourceLogstashPipelineSet()
+	raw := map[string]interface{}{
+		"pipelines": map[string]interface{}{
+			"team-a-web": "input { beats {} }",
+			"team-a-db":  "input { jdbc {} }",
+		},
+		"prune_prefix": "team-a-",
+		"dry_run":      true,
+	}
+
+	// Dry run: pipelines are created, unmanaged ones are only reported
+	state := testApplyResource(t, r, nil, raw, kibana.meta())
+	for _, id := range []string{"team-a-web", "team-a-db", "team-a-legacy", "team-b-web"} {
+		if kibana.get(api.DefaultSpaceID, id) == nil {
+			t.Fatalf("expected pipeline %s to exist", id)
+		}
+	}
+	if state.ID != "default/team-a-*" || state.Attributes["prune_ids.#"] != "1" || state.Attributes["prune_ids.0"] != "team-a-legacy" {
+		t.Fatalf("unexpected state %v", state.Attributes)
+	}
+	d := r.Data(state)
+	diags := resourceLogstashPipelineSetRead(context.Background(), d, kibana.meta())
+	if len(diags) != 1 || diags[0].Summary != "Logstash pipelines would be pruned" || !strings.Contains(diags[0].Detail, "team-a-legacy") {
+		t.Fatalf("expected a dry run warning, got %v", diags)
+	}
+
+	// Semantic changes only are planned
+	raw["pipelines"] = map[string]interface{}{
+		"team-a-web": "input {\n  beats {}\n}",
+		"team-a-db":  "input { jdbc {} }",
+	}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Fatalf("expected empty plan, got %v", diff.Attributes)
+	}
+
+	// Pruning: the removed pipeline and the unmanaged one are deleted
+	raw["pipelines"] = map[string]interface{}{
+		"team-a-web": "input { beats { port => 5044 } }",
+	}
+	raw["dry_run"] = false
+	state = testApplyResource(t, r, state, raw, kibana.meta())
+	for id, exists := range map[string]bool{"team-a-web": true, "team-a-db": false, "team-a-legacy": false, "team-b-web": true} {
+		if (kibana.get(api.DefaultSpaceID, id) != nil) != exists {
+			t.Fatalf("expected pipeline %s existence to be %t", id, exists)
+		}
+	}
+	if pipeline := kibana.get(api.DefaultSpaceID, "team-a-web").Pipeline; pipeline != "input { beats { port => 5044 } }" {
+		t.Fatalf("expected pipeline to be updated, got %q", pipeline)
+	}
+	if state.Attributes["prune_ids.#"] != "1" || state.Attributes["prune_ids.0"] != "team-a-legacy" {
+		t.Fatalf("expected the pruned pipelines to be kept until refreshed, got %v", state.Attributes)
+	}
+	state, diags = r.RefreshWithoutUpgrade(context.Background(), state, kibana.meta())
+	if diags.HasError() || state.Attributes["prune_ids.#"] != "0" {
+		t.Fatalf("expected no pipeline to prune, got %v %v", diags, state.Attributes)
+	}
+
+	// Pipelines created outside of Terraform are planned for pruning, the ones created after the plan are kept
+	kibana.put(api.DefaultSpaceID, "team-a-manual", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attribute := diff.Attributes["prune_ids.0"]; attribute == nil || attribute.New != "team-a-manual" {
+		t.Fatalf("expected team-a-manual to be planned for pruning, got %v", diff.Attributes)
+	}
+	kibana.put(api.DefaultSpaceID, "team-a-late", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+	state, diags = r.Apply(context.Background(), state, diff, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if kibana.get(api.DefaultSpaceID, "team-a-manual") != nil || kibana.get(api.DefaultSpaceID, "team-a-late") == nil {
+		t.Fatal("expected only the planned pipeline to be pruned")
+	}
+	kibana.delete(api.DefaultSpaceID, "team-a-late")
+	kibana.put(api.DefaultSpaceID, "team-a-manual", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+
+	// Only the pipelines of the set are deleted with it
+	d = r.Data(state)
+	if diags := resourceLogstashPipelineSetDelete(context.Background(), d, kibana.meta()); diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if kibana.get(api.DefaultSpaceID, "team-a-web") != nil || kibana.get(api.DefaultSpaceID, "team-a-manual") == nil {
+		t.Fatal("expected only the pipelines of the set to be deleted")
+	}
+}
+
+func TestResourceLogstashPipelineSet_pruneRegex(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put("team-a", "filebeat-old", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+	kibana.put("team-a", "metricbeat", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+
+	r := resourceLogstashPipelineSet()
+	raw := map[string]interface{}{
+		"space_id":    "team-a",
+		"pipelines":   map[string]interface{}{"filebeat": "input { beats {} }"},
+		"prune_regex": "^filebeat",
+	}
+
+	// Pipelines pruned by the creation are planned as well
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attribute := diff.Attributes["prune_ids.0"]; attribute == nil || attribute.New != "filebeat-old" {
+		t.Fatalf("expected filebeat-old to be planned for pruning, got %v", diff.Attributes)
+	}
+
+	state := testApplyResource(t, r, nil, raw, kibana.meta())
+
+	if state.ID != "team-a/^filebeat" {
+		t.Fatalf("unexpected ID %q", state.ID)
+	}
+	if kibana.get("team-a", "filebeat") == nil || kibana.get("team-a", "filebeat-old") != nil || kibana.get("team-a", "metricbeat") == nil {
+		t.Fatal("expected only filebeat-old to be pruned")
+	}
+}
+
+func TestResourceLogstashPipelineSet_updateScope(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "web-legacy", api.LogstashConfiguration{Pipeline: "input { stdin {} }"})
+
+	r := resourceLogstashPipelineSet()
+	raw := map[string]interface{}{
+		"pipelines":    map[string]interface{}{"team-a-web": "input { beats {} }"},
+		"prune_prefix": "team-a-",
+	}
+	state := testApplyResource(t, r, nil, raw, kibana.meta())
+
+	// Changing the scope updates the set in place, its pipelines are kept
+	delete(raw, "prune_prefix")
+	raw["prune_regex"] = "web"
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("expected an update in place, got %v", diff.Attributes)
+	}
+	state, diags := r.Apply(context.Background(), state, diff, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if kibana.get(api.DefaultSpaceID, "team-a-web") == nil || kibana.get(api.DefaultSpaceID, "web-legacy") != nil {
+		t.Fatal("expected web-legacy only to be pruned")
+	}
+	if state.Attributes["prune_regex"] != "web" {
+		t.Fatalf("unexpected state %v", state.Attributes)
+	}
+}
+
+func TestResourceLogstashPipelineSetRead_modified(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+
+	r := resourceLogstashPipelineSet()
+	state := testApplyResource(t, r, nil, map[string]interface{}{
+		"pipelines":    map[string]interface{}{"team-a-web": "input { beats {} }", "team-a-db": "input { jdbc {} }"},
+		"prune_prefix": "team-a-",
+	}, kibana.meta())
+	if diags := resourceLogstashPipelineSetRead(context.Background(), r.Data(state), kibana.meta()); len(diags) != 0 {
+		t.Fatalf("expected no warning, got %v", diags)
+	}
+
+	settings := expandSettings(nil)
+	settings.PipelineWorkers = 4
+	kibana.put(api.DefaultSpaceID, "team-a-web", api.LogstashConfiguration{Pipeline: "input { beats {} }", Settings: settings})
+	kibana.put(api.DefaultSpaceID, "team-a-db", api.LogstashConfiguration{Pipeline: "input { jdbc {} }", Description: "Database", Settings: expandSettings(nil)})
+	diags := resourceLogstashPipelineSetRead(context.Background(), r.Data(state), kibana.meta())
+	if len(diags) != 1 || diags[0].Summary != "Logstash pipelines modified outside of Terraform" || !strings.Contains(diags[0].Detail, "team-a-db, team-a-web") {
+		t.Fatalf("expected a modification warning, got %v", diags)
+	}
+}
+
+func TestResourceLogstashPipelineSetCustomizeDiff(t *testing.T) {
+	tests := []struct {
+		config          map[string]interface{}
+		secretDetection string
+		expected        string
+	}{
+		{map[string]interface{}{"pipelines": map[string]interface{}{"a": "input { stdin {} }"}, "prune_prefix": "a"}, secretDetectionError, ""},
+		{map[string]interface{}{"pipelines": map[string]interface{}{"a": "input { stdin {}"}, "prune_prefix": "a"}, secretDetectionWarn, `pipelines["a"]: invalid pipeline definition`},
+		{map[string]interface{}{"pipelines": map[string]interface{}{"a": `output { elasticsearch { password => "changeme" } }`}, "prune_prefix": "a"}, secretDetectionWarn, ""},
+		{map[string]interface{}{"pipelines": map[string]interface{}{"a": `output { elasticsearch { password => "changeme" } }`}, "prune_prefix": "a"}, secretDetectionError, `pipelines["a"]: line 1, column 26: password of plugin elasticsearch has a literal value`},
+		// Would prune every pipeline of the space
+		{map[string]interface{}{"pipelines": map[string]interface{}{"a": "input { stdin {} }"}, "prune_regex": "", "space_id": "default"}, secretDetectionWarn, "either prune_prefix or prune_regex must be a non empty string"},
+	}
+
+	kibana := newMockKibana()
+	defer kibana.Close()
+	for _, test := range tests {
+		meta := kibana.meta()
+		meta.secretDetection = test.secretDetection
+		_, err := resourceLogstashPipelineSet().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(test.config), meta)
+		if test.expected == "" && err != nil {
+			t.Fatalf("expected no error for %v, got %v", test.config, err)
+		}
+		if test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+			t.Fatalf("expected error %q for %v, got %v", test.expected, test.config, err)
+		}
+	}
+}
+
+func TestResourceLogstashPipelineSetValidate_emptyScope(t *testing.T) {
+	for _, scope := range []string{"prune_prefix", "prune_regex"} {
+		diags := resourceLogstashPipelineSet().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+			"pipelines": map[string]interface{}{"a": "input { stdin {} }"},
+			scope:       "",
+		}))
+		if !diags.HasError() {
+			t.Fatalf("expected an error for an empty %s", scope)
+		}
+	}
+
+	if _, err := pipelineSetScope("", ""); err == nil {
+		t.Fatalf("expected an error for an empty scope")
+	}
+}
+
+// testApplyResource plans and applies raw as Terraform would, state is nil for a creation
+func testApplyResource(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta *providerMeta) *terraform.InstanceState {
+	t.Helper()
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), meta)
+	if err != nil {
+		t.Fatalf("expected no plan error, got %v", err)
+	}
+	newState, diags := r.Apply(context.Background(), state, diff, meta)
+	if diags.HasError() {
+		t.Fatalf("expected no apply error, got %v", diags)
+	}
+	return newState
+}
@@ -3,16 +3,25 @@ package elastic
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/skysoft-atm/terraform-provider-elastic/api"
 )
 
 func TestAccElasticLogstashPipeline_basic(t *testing.T) {
 	id := "toto2"
-	pipeline := "test pipeline content"
+	pipeline := "input { stdin {} } output { stdout {} }"
 	description := "example description"
 
 	resource.Test(t, resource.TestCase{
@@ -50,7 +59,7 @@ func TestAccElasticLogstashPipelineDataSource(t *testing.T) {
 }
 
 func testAccCheckElasticLogstashDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*api.Client)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "elastic_logstash_pipeline" {
@@ -58,11 +67,15 @@ func testAccCheckElasticLogstashDestroy(s *terraform.State) error {
 		}
 
 		// Try to find the task
-		_, err := client.GetLogstashPipeline(context.Background(), rs.Primary.ID)
+		spaceID, pipelineID := parsePipelineID(rs.Primary.ID)
+		_, err := client.WithSpace(spaceID).GetLogstashPipeline(context.Background(), pipelineID)
 
 		if err == nil {
 			return fmt.Errorf("Task still exists")
 		}
+		if !api.IsNotFound(err) {
+			return err
+		}
 	}
 	return nil
 }
@@ -115,3 +128,546 @@ func testAccCheckElasticLogstashPipelineAttributes(pipeline *api.LogstashPipelin
 		return nil
 	}
 }
+
+func TestPipelineID(t *testing.T) {
+	tests := []struct {
+		spaceID    string
+		pipelineID string
+		id         string
+	}{
+		{api.DefaultSpaceID, "filebeat", "filebeat"},
+		{"team-a", "filebeat", "team-a/filebeat"},
+	}
+
+	for _, test := range tests {
+		if id := buildPipelineID(test.spaceID, test.pipelineID); id != test.id {
+			t.Fatalf("expected ID %q, got %q", test.id, id)
+		}
+		spaceID, pipelineID := parsePipelineID(test.id)
+		if spaceID != test.spaceID || pipelineID != test.pipelineID {
+			t.Fatalf("expected %q to be parsed as (%q, %q), got (%q, %q)", test.id, test.spaceID, test.pipelineID, spaceID, pipelineID)
+		}
+	}
+}
+
+func TestResourceLogstashPipelineRead_removedOutsideTerraform(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "filebeat", api.LogstashConfiguration{Pipeline: "input {}", Settings: &api.Settings{}})
+
+	d := resourceLogstashPipeline().TestResourceData()
+	d.SetId("filebeat")
+
+	diags := resourceLogstashPipelineRead(context.Background(), d, kibana.meta())
+	if diags.HasError() || d.Id() != "filebeat" {
+		t.Fatalf("expected pipeline to be read, got %v", diags)
+	}
+
+	kibana.delete(api.DefaultSpaceID, "filebeat")
+	diags = resourceLogstashPipelineRead(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a warning, got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected pipeline to be removed from state, got ID %q", d.Id())
+	}
+}
+
+func TestResourceLogstashPipelineCreate_conflict(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.failPuts(http.StatusConflict)
+
+	d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, map[string]interface{}{
+		"pipeline_id": "filebeat",
+		"pipeline":    "input { beats {} }",
+	})
+	diags := resourceLogstashPipelineCreate(context.Background(), d, kibana.meta())
+	if !diags.HasError() || diags[0].Summary != "Logstash pipeline modified concurrently" {
+		t.Fatalf("expected a concurrent modification error, got %v", diags)
+	}
+
+	kibana.failPuts(http.StatusInternalServerError)
+	diags = resourceLogstashPipelineCreate(context.Background(), d, kibana.meta())
+	if !diags.HasError() || diags[0].Summary == "Logstash pipeline modified concurrently" {
+		t.Fatalf("expected the API error, got %v", diags)
+	}
+}
+
+func TestDataSourceLogstashPipelineRead_unknown(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+
+	d := dataSourceLogstashPipeline().TestResourceData()
+	d.Set("pipeline_id", "unknown")
+
+	diags := dataSourceLogstashPipelineRead(context.Background(), d, kibana.meta())
+	if !diags.HasError() || diags[0].Summary != "Logstash pipeline not found" {
+		t.Fatalf("expected not found error, got %v", diags)
+	}
+}
+
+func TestResourceLogstashPipelineImport(t *testing.T) {
+	tests := []struct {
+		id              string
+		providerSpaceID string
+		expectedID      string
+		expectedSpaceID string
+		expectedError   bool
+	}{
+		{"filebeat", "", "filebeat", api.DefaultSpaceID, false},
+		{"filebeat", "team-a", "team-a/filebeat", "team-a", false},
+		{"team-b/filebeat", "team-a", "team-b/filebeat", "team-b", false},
+		{"default/filebeat", "", "filebeat", api.DefaultSpaceID, false},
+		{"/filebeat", "", "", "", true},
+		{"team-a/", "", "", "", true},
+	}
+
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put("team-b", "filebeat", api.LogstashConfiguration{Pipeline: "input { beats {} }", Username: "bob"})
+	for _, test := range tests {
+		c := kibana.client()
+		c.SpaceID = test.providerSpaceID
+		d := resourceLogstashPipeline().TestResourceData()
+		d.SetId(test.id)
+
+		res, err := resourceLogstashPipelineImport(context.Background(), d, &providerMeta{client: c})
+		if test.expectedError {
+			if err == nil {
+				t.Fatalf("expected error importing %q", test.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected no error importing %q, got %s", test.id, err)
+		}
+		if len(res) != 1 || res[0].Id() != test.expectedID || res[0].Get("space_id") != test.expectedSpaceID || res[0].Get("pipeline_id") != "filebeat" {
+			t.Fatalf("unexpected import result for %q: ID %q, space_id %q", test.id, res[0].Id(), res[0].Get("space_id"))
+		}
+		// The imported pipeline is the reference to detect modifications made outside of Terraform
+		if exists := test.expectedSpaceID == "team-b"; (res[0].Get("applied_username") == "bob") != exists || (res[0].Get("applied_last_modified") != "") != exists {
+			t.Fatalf("unexpected applied modification for %q: %q by %q", test.id, res[0].Get("applied_last_modified"), res[0].Get("applied_username"))
+		}
+	}
+}
+
+func TestAccElasticLogstashPipeline_import(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "handmade", api.LogstashConfiguration{
+		Description: "Created in Kibana UI",
+		Pipeline:    "input { stdin {} } output { stdout {} }",
+		Settings:    api.NewLogstashPipelineSettings(50, 125, 1, 1024, "1gb", "memory"),
+	})
+	kibana.put("team-a", "handmade", api.LogstashConfiguration{
+		Pipeline: "input { stdin {} } output { stdout {} }",
+		Settings: api.NewLogstashPipelineSettings(50, 250, 2, 1024, "1gb", "persisted"),
+	})
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:        kibana.providerConfig() + testAccCheckElasticLogstashPipelineConfigImport("imported", "handmade", ""),
+				ResourceName:  "elastic_logstash_pipeline.imported",
+				ImportState:   true,
+				ImportStateId: "handmade",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 {
+						return fmt.Errorf("expected one imported pipeline, got %d", len(states))
+					}
+					attributes := map[string]string{
+						"pipeline_id":                "handmade",
+						"space_id":                   api.DefaultSpaceID,
+						"description":                "Created in Kibana UI",
+						"username":                   "elastic",
+						"pipeline":                   "input { stdin {} } output { stdout {} }",
+						"settings.#":                 "1",
+						"settings.0.batch_delay":     "50",
+						"settings.0.batch_size":      "125",
+						"settings.0.workers":         "1",
+						"settings.0.queue_max_bytes": "1gb",
+						"settings.0.queue_type":      "memory",
+					}
+					for key, expected := range attributes {
+						if value := states[0].Attributes[key]; value != expected {
+							return fmt.Errorf("expected %s to be %q, got %q", key, expected, value)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Config:        kibana.providerConfig() + testAccCheckElasticLogstashPipelineConfigImport("imported", "handmade", "team-a"),
+				ResourceName:  "elastic_logstash_pipeline.imported",
+				ImportState:   true,
+				ImportStateId: "team-a/handmade",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if states[0].ID != "team-a/handmade" || states[0].Attributes["settings.0.queue_type"] != "persisted" {
+						return fmt.Errorf("unexpected imported state %v", states[0].Attributes)
+					}
+					return nil
+				},
+			},
+			{
+				// Imported state must match the state of a pipeline managed by Terraform, hence an empty plan
+				Config: kibana.providerConfig() + testAccCheckElasticLogstashPipelineConfigImport("managed", "managed", "team-a"),
+			},
+			{
+				Config:            kibana.providerConfig() + testAccCheckElasticLogstashPipelineConfigImport("managed", "managed", "team-a"),
+				ResourceName:      "elastic_logstash_pipeline.managed",
+				ImportState:       true,
+				ImportStateId:     "team-a/managed",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckElasticLogstashPipelineConfigImport(name, id, spaceID string) string {
+	space := ""
+	if spaceID != "" {
+		space = fmt.Sprintf("space_id = %q", spaceID)
+	}
+	return fmt.Sprintf(`
+	resource "elastic_logstash_pipeline" "%s" {
+		pipeline_id = "%s"
+		%s
+		pipeline    = "input { stdin {} } output { stdout {} }"
+		description = "Created in Kibana UI"
+	}
+	`, name, id, space)
+}
+
+func TestValidateLogstashPipeline(t *testing.T) {
+	tests := []struct {
+		pipeline      string
+		expectedError string
+	}{
+		{"input { stdin {} } output { stdout {} }", ""},
+		{"", ""},
+		{"test pipeline content", `line 1, column 1: expected one of input, filter, output sections, got "test"`},
+		{"input {\n  stdin {\n}", `line 3, column 2: expected "}", got end of input`},
+	}
+
+	for _, test := range tests {
+		diags := validateLogstashPipeline(test.pipeline, cty.Path{cty.GetAttrStep{Name: "pipeline"}})
+		if test.expectedError == "" {
+			if diags.HasError() {
+				t.Fatalf("expected no error for %q, got %v", test.pipeline, diags)
+			}
+			continue
+		}
+		if !diags.HasError() || diags[0].Detail != test.expectedError {
+			t.Fatalf("expected error %q for %q, got %v", test.expectedError, test.pipeline, diags)
+		}
+	}
+}
+
+func TestValidateLogstashPipeline_secrets(t *testing.T) {
+	// Reported according to secret_detection by create, update and read, which validation has no access to
+	diags := resourceLogstashPipeline().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"pipeline_id": "filebeat",
+		"pipeline":    `output { elasticsearch { cloud_auth => "elastic:changeme" } }`,
+	}))
+	if len(diags) != 0 {
+		t.Fatalf("expected no diagnostic when validating the configuration, got %v", diags)
+	}
+}
+
+func TestResourceLogstashPipelineCreate_secretDetection(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+
+	for _, test := range []struct {
+		mode     string
+		warnings int
+	}{
+		{secretDetectionOff, 0},
+		{secretDetectionWarn, 1},
+	} {
+		d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, map[string]interface{}{
+			"pipeline_id": "filebeat",
+			"pipeline":    `output { elasticsearch { cloud_auth => "elastic:changeme" } }`,
+		})
+		meta := kibana.meta()
+		meta.secretDetection = test.mode
+
+		diags := resourceLogstashPipelineCreate(context.Background(), d, meta)
+		if diags.HasError() || len(diags) != test.warnings {
+			t.Fatalf("expected %d warnings with secret_detection = %q, got %v", test.warnings, test.mode, diags)
+		}
+	}
+}
+
+func TestSuppressEquivalentPipeline(t *testing.T) {
+	old := "input { stdin {} }\noutput { stdout { codec => rubydebug } }"
+	tests := []struct {
+		new          string
+		semanticDiff bool
+		suppressed   bool
+	}{
+		{"input {\n  stdin {}\n}\n# debug\noutput {\n  stdout {\n    codec => \"rubydebug\"\n  }\n}\n", true, true},
+		{"input {\n  stdin {}\n}\n# debug\noutput {\n  stdout {\n    codec => \"rubydebug\"\n  }\n}\n", false, false},
+		{"input { stdin {} }\noutput { stdout { codec => json } }", true, false},
+	}
+
+	for _, test := range tests {
+		d := schema.TestResourceDataRaw(t, resourceLogstashPipeline().Schema, map[string]interface{}{
+			"pipeline_id":   "test",
+			"pipeline":      test.new,
+			"semantic_diff": test.semanticDiff,
+		})
+		if suppressed := suppressEquivalentPipeline("pipeline", old, test.new, d); suppressed != test.suppressed {
+			t.Fatalf("expected suppressed to be %t for %q (semantic_diff = %t)", test.suppressed, test.new, test.semanticDiff)
+		}
+	}
+}
+
+func TestResourceLogstashPipelineRead_secretDetection(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "filebeat", api.LogstashConfiguration{
+		Pipeline: `output { elasticsearch { cloud_id => "${CLOUD_ID}" cloud_auth => "elastic:changeme" } }`,
+		Settings: &api.Settings{},
+	})
+
+	tests := []struct {
+		mode     string
+		warnings int
+	}{
+		{secretDetectionOff, 0},
+		{secretDetectionWarn, 1},
+		// Reading must not fail, the error is raised at plan time
+		{secretDetectionError, 1},
+	}
+
+	for _, test := range tests {
+		d := resourceLogstashPipeline().TestResourceData()
+		d.SetId("filebeat")
+		meta := kibana.meta()
+		meta.secretDetection = test.mode
+
+		diags := resourceLogstashPipelineRead(context.Background(), d, meta)
+		if diags.HasError() || len(diags) != test.warnings {
+			t.Fatalf("expected %d warnings with secret_detection = %q, got %v", test.warnings, test.mode, diags)
+		}
+		if test.warnings > 0 && diags[0].Summary != "Plaintext secret in pipeline definition" {
+			t.Fatalf("unexpected warning %v", diags[0])
+		}
+	}
+}
+
+func TestResourceLogstashPipelineCustomizeDiff(t *testing.T) {
+	tests := []struct {
+		mode          string
+		pipeline      string
+		expectedError bool
+	}{
+		{secretDetectionError, `output { elasticsearch { password => "changeme" } }`, true},
+		{secretDetectionError, `output { elasticsearch { password => "${ES_PWD}" } }`, false},
+		{secretDetectionWarn, `output { elasticsearch { password => "changeme" } }`, false},
+		{secretDetectionOff, `output { elasticsearch { password => "changeme" } }`, false},
+	}
+
+	for _, test := range tests {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"pipeline_id": "filebeat",
+			"pipeline":    test.pipeline,
+			"settings":    []interface{}{map[string]interface{}{}},
+		})
+		meta := &providerMeta{secretDetection: test.mode}
+		_, err := resourceLogstashPipeline().Diff(context.Background(), nil, config, meta)
+		if test.expectedError != (err != nil) {
+			t.Fatalf("expected error to be %t for %q (secret_detection = %q), got %v", test.expectedError, test.pipeline, test.mode, err)
+		}
+	}
+}
+
+func TestResourceLogstashPipeline_pipelineAddresses(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	kibana.put(api.DefaultSpaceID, "weblogs", api.LogstashConfiguration{
+		Pipeline: `input { pipeline { address => weblogs } } output { pipeline { send_to => ["archive", "metrics"] } }`,
+	})
+
+	d := resourceLogstashPipeline().TestResourceData()
+	d.SetId("weblogs")
+	diags := resourceLogstashPipelineRead(context.Background(), d, kibana.meta())
+	if diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	if inputs := d.Get("pipeline_inputs_addresses"); !reflect.DeepEqual(inputs, []interface{}{"weblogs"}) {
+		t.Fatalf("unexpected input addresses %v", inputs)
+	}
+	if outputs := d.Get("pipeline_output_addresses"); !reflect.DeepEqual(outputs, []interface{}{"archive", "metrics"}) {
+		t.Fatalf("unexpected output addresses %v", outputs)
+	}
+
+	// Addresses of a new definition are known at plan time
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"pipeline_id": "weblogs",
+		"pipeline":    `input { pipeline { address => weblogs } } output { pipeline { send_to => archive } }`,
+	})
+	diff, err := resourceLogstashPipeline().Diff(context.Background(), d.State(), config, kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attribute := diff.Attributes["pipeline_output_addresses.#"]; attribute == nil || attribute.New != "1" {
+		t.Fatalf("expected output addresses to be planned, got %v", diff.Attributes)
+	}
+	if attribute := diff.Attributes["pipeline_output_addresses.1"]; attribute == nil || !attribute.NewRemoved {
+		t.Fatalf("expected metrics address to be removed, got %v", diff.Attributes)
+	}
+	if _, ok := diff.Attributes["pipeline_inputs_addresses.#"]; ok {
+		t.Fatalf("expected input addresses not to change, got %v", diff.Attributes)
+	}
+}
+
+func TestResourceLogstashPipelineUpdate_modifiedOutsideTerraform(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	r := resourceLogstashPipeline()
+	raw := map[string]interface{}{
+		"pipeline_id": "hotfix",
+		"pipeline":    "input { stdin {} }",
+	}
+	state := testApplyResource(t, r, nil, raw, kibana.meta())
+	if state.Attributes["last_modified"] == "" || state.Attributes["username"] != "elastic" {
+		t.Fatalf("expected last_modified and username to be set, got %v", state.Attributes)
+	}
+
+	// Hot fix in Kibana UI after the last refresh
+	kibana.put(api.DefaultSpaceID, "hotfix", api.LogstashConfiguration{Pipeline: "input { stdin { id => hotfix } }", Username: "bob"})
+
+	raw["pipeline"] = "input { beats {} }"
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attribute := diff.Attributes["last_modified"]; attribute == nil || !attribute.NewComputed {
+		t.Fatalf("expected last_modified to be planned, got %v", diff.Attributes)
+	}
+	_, diags := r.Apply(context.Background(), state, diff, kibana.meta())
+	if !diags.HasError() || diags[0].Summary != "Logstash pipeline modified outside of Terraform" {
+		t.Fatalf("expected a modification error, got %v", diags)
+	}
+	for _, expected := range []string{`modified by "bob"`, "(- state, + Kibana):\n- input { stdin {} }\n+ input { stdin { id => hotfix } }\n", "force_overwrite"} {
+		if !strings.Contains(diags[0].Detail, expected) {
+			t.Fatalf("expected %q in %q", expected, diags[0].Detail)
+		}
+	}
+	if pipeline := kibana.get(api.DefaultSpaceID, "hotfix").Pipeline; pipeline != "input { stdin { id => hotfix } }" {
+		t.Fatalf("expected hot fix to be kept, got %q", pipeline)
+	}
+
+	raw["force_overwrite"] = true
+	state = testApplyResource(t, r, state, raw, kibana.meta())
+	if pipeline := kibana.get(api.DefaultSpaceID, "hotfix").Pipeline; pipeline != "input { beats {} }" {
+		t.Fatalf("expected hot fix to be overwritten, got %q", pipeline)
+	}
+	if state.Attributes["username"] != "elastic" {
+		t.Fatalf("expected username to be read again, got %v", state.Attributes)
+	}
+}
+
+func TestResourceLogstashPipelineUpdate_modifiedBeforeRefresh(t *testing.T) {
+	kibana := newMockKibana()
+	defer kibana.Close()
+	r := resourceLogstashPipeline()
+	raw := map[string]interface{}{
+		"pipeline_id": "hotfix",
+		"pipeline":    "input { stdin {} }",
+	}
+	state := testApplyResource(t, r, nil, raw, kibana.meta())
+
+	// Hot fix in Kibana UI, then read by the refresh preceding the plan
+	kibana.put(api.DefaultSpaceID, "hotfix", api.LogstashConfiguration{Pipeline: "input { stdin { id => hotfix } }", Username: "bob"})
+	d := r.Data(state)
+	if diags := resourceLogstashPipelineRead(context.Background(), d, kibana.meta()); diags.HasError() {
+		t.Fatalf("expected no error, got %v", diags)
+	}
+	state = d.State()
+	if state.Attributes["username"] != "bob" || state.Attributes["applied_username"] != "elastic" {
+		t.Fatalf("expected the refresh to keep the applied modification, got %v", state.Attributes)
+	}
+
+	// Planned as a revert of the hot fix, which must be refused
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, diags := r.Apply(context.Background(), state, diff, kibana.meta())
+	if !diags.HasError() || diags[0].Summary != "Logstash pipeline modified outside of Terraform" {
+		t.Fatalf("expected a modification error, got %v", diags)
+	}
+	if pipeline := kibana.get(api.DefaultSpaceID, "hotfix").Pipeline; pipeline != "input { stdin { id => hotfix } }" {
+		t.Fatalf("expected hot fix to be kept, got %q", pipeline)
+	}
+
+	// Reporting the hot fix in the configuration acknowledges it, without updating the pipeline
+	raw["pipeline"] = "input { stdin { id => hotfix } }"
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), kibana.meta())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attribute := diff.Attributes["applied_username"]; attribute == nil || attribute.Old != "elastic" || attribute.New != "bob" {
+		t.Fatalf("expected the modification to be acknowledged, got %v", diff.Attributes)
+	}
+	if _, ok := diff.Attributes["pipeline"]; ok {
+		t.Fatalf("expected no pipeline change, got %v", diff.Attributes)
+	}
+	state = testApplyResource(t, r, state, raw, kibana.meta())
+	if state.Attributes["applied_username"] != "bob" || state.Attributes["applied_last_modified"] != state.Attributes["last_modified"] {
+		t.Fatalf("expected the modification to be acknowledged, got %v", state.Attributes)
+	}
+
+	// Further changes are applied
+	raw["pipeline"] = "input { beats {} }"
+	state = testApplyResource(t, r, state, raw, kibana.meta())
+	if pipeline := kibana.get(api.DefaultSpaceID, "hotfix").Pipeline; pipeline != "input { beats {} }" {
+		t.Fatalf("expected pipeline to be updated, got %q", pipeline)
+	}
+	if state.Attributes["applied_username"] != "elastic" {
+		t.Fatalf("expected the applied modification to be recorded, got %v", state.Attributes)
+	}
+}
+
+func TestResourceLogstashPipeline_timeouts(t *testing.T) {
+	slowKibana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client is only noticed gone once the body is read
+		ioutil.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slowKibana.Close()
+	c := api.NewClient("elastic:changeme", slowKibana.URL)
+	c.MaxRetries = 0
+	c.SetRequestTimeout(0)
+
+	r := resourceLogstashPipeline()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"pipeline_id": "slow",
+		"pipeline":    "input { stdin {} }",
+		"timeouts":    map[string]interface{}{"create": "100ms"},
+	})
+	diff, err := r.Diff(context.Background(), nil, config, &providerMeta{client: c})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	start := time.Now()
+	_, diags := r.Apply(context.Background(), nil, diff, &providerMeta{client: c})
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "context deadline exceeded") {
+		t.Fatalf("expected a deadline error, got %v", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the create timeout to interrupt the request, took %s", elapsed)
+	}
+}
@@ -22,7 +22,9 @@ variable "cloud_id" {
 }
 
 provider "elastic" {
+  // kibana_url can be omitted, the Kibana endpoint is then derived from cloud_id
   kibana_url = var.kibana_url
+  cloud_id   = var.cloud_id
   cloud_auth = var.cloud_auth
 }
 
@@ -30,14 +32,19 @@ data "elastic_logstash_pipeline" "filebeat" {
   pipeline_id = "filebeat"
 }
 
+// Unlike templatefile, Logstash %{field} references do not need to be escaped
+data "elastic_logstash_pipeline_template" "test" {
+  filename = "${path.module}/pipeline.conf"
+  // CLOUD_AUTH is left for Logstash to resolve from its keystore, not to be stored in Kibana
+  vars = {
+    CLOUD_ID = var.cloud_id
+  }
+}
+
 resource "elastic_logstash_pipeline" "test" {
   pipeline_id = "test"
-  pipeline = templatefile("${path.module}/pipeline.conf", {
-    CLOUD_ID   = var.cloud_id
-    CLOUD_AUTH = var.cloud_auth
-  })
+  pipeline    = data.elastic_logstash_pipeline_template.test.pipeline
   description = "Description"
-  settings {}
 }
 
 // Output Filebeat
@@ -5,12 +5,13 @@ go 1.14
 require (
 	cloud.google.com/go v0.68.0 // indirect
 	github.com/aws/aws-sdk-go v1.31.9 // indirect
-	github.com/hashicorp/hcl/v2 v2.6.0 // indirect
+	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
+	github.com/hashicorp/hcl/v2 v2.6.0
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.0.4
 	github.com/lithammer/shortuuid/v3 v3.0.4
 	github.com/mitchellh/go-testing-interface v1.14.1 // indirect
 	github.com/stretchr/testify v1.6.1
-	github.com/zclconf/go-cty v1.5.1 // indirect
+	github.com/zclconf/go-cty v1.5.1
 	golang.org/x/tools v0.0.0-20201008025239-9df69603baec // indirect
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
@@ -0,0 +1,93 @@
+// Package pipelinesettings maps the pipeline settings of the API to the attributes of the settings block
+// of the provider resources, shared by the provider and the elastic-pipelines command
+package pipelinesettings
+
+import "github.com/skysoft-atm/terraform-provider-elastic/api"
+
+// Defaults returns the attributes of the settings block with their Logstash default values
+func Defaults() map[string]interface{} {
+	return map[string]interface{}{
+		"batch_delay":                      api.DefaultPipelineBatchDelay,
+		"batch_size":                       api.DefaultPipelineBatchSize,
+		"workers":                          api.DefaultPipelineWorkers,
+		"ordered":                          api.DefaultPipelineOrdered,
+		"ecs_compatibility":                "",
+		"queue_checkpoint_writes":          api.DefaultQueueCheckpointWrites,
+		"queue_checkpoint_acks":            api.DefaultQueueCheckpointAcks,
+		"queue_checkpoint_interval":        api.DefaultQueueCheckpointInterval,
+		"queue_checkpoint_retry":           api.DefaultQueueCheckpointRetry,
+		"queue_max_bytes":                  api.DefaultQueueMaxBytes,
+		"queue_max_events":                 api.DefaultQueueMaxEvents,
+		"queue_page_capacity":              api.DefaultQueuePageCapacity,
+		"queue_drain":                      api.DefaultQueueDrain,
+		"queue_type":                       api.DefaultQueueType,
+		"dead_letter_queue_enable":         api.DefaultDeadLetterQueueEnable,
+		"dead_letter_queue_max_bytes":      api.DefaultDeadLetterQueueMaxBytes,
+		"dead_letter_queue_storage_policy": api.DefaultDeadLetterQueueStoragePolicy,
+		"dead_letter_queue_flush_interval": api.DefaultDeadLetterQueueFlushInterval,
+	}
+}
+
+// Flatten returns the attributes of the settings block matching settings, settings not returned by Kibana
+// (null or missing, e.g. pipelines created in Kibana UI or by previous versions of the provider) get their Logstash
+// default value
+func Flatten(settings *api.Settings) map[string]interface{} {
+	s := Defaults()
+	if settings == nil {
+		return s
+	}
+	setIfNotNull(s, "workers", settings.PipelineWorkers)
+	setIfNotNull(s, "batch_size", settings.PipelineBatchSize)
+	setIfNotNull(s, "batch_delay", settings.PipelineBatchDelay)
+	setIfNotNull(s, "ordered", settings.PipelineOrdered)
+	setIfNotNull(s, "ecs_compatibility", settings.PipelineECSCompatibility)
+	setIfNotNull(s, "queue_checkpoint_writes", settings.QueueCheckpointWrites)
+	setIfNotNull(s, "queue_checkpoint_acks", settings.QueueCheckpointAcks)
+	setIfNotNull(s, "queue_checkpoint_interval", settings.QueueCheckpointInterval)
+	setIfNotNull(s, "queue_checkpoint_retry", settings.QueueCheckpointRetry)
+	setIfNotNull(s, "queue_max_bytes", settings.QueueMaxBytes)
+	setIfNotNull(s, "queue_max_events", settings.QueueMaxEvents)
+	setIfNotNull(s, "queue_page_capacity", settings.QueuePageCapacity)
+	setIfNotNull(s, "queue_drain", settings.QueueDrain)
+	setIfNotNull(s, "queue_type", settings.QueueType)
+	setIfNotNull(s, "dead_letter_queue_enable", settings.DeadLetterQueueEnable)
+	setIfNotNull(s, "dead_letter_queue_max_bytes", settings.DeadLetterQueueMaxBytes)
+	setIfNotNull(s, "dead_letter_queue_storage_policy", settings.DeadLetterQueueStoragePolicy)
+	setIfNotNull(s, "dead_letter_queue_flush_interval", settings.DeadLetterQueueFlushInterval)
+	return s
+}
+
+// Attributes returns the attributes of the settings block matching settings, the ones having their
+// Logstash default value excepted (e.g. to generate configurations)
+func Attributes(settings *api.Settings) map[string]interface{} {
+	attributes := Flatten(settings)
+	for name, value := range Defaults() {
+		if attributes[name] == value {
+			delete(attributes, name)
+		}
+	}
+	return attributes
+}
+
+// setIfNotNull sets the setting unless Kibana did not return it: nil pointers and, for the settings
+// without pointer, zero values (the API omits them)
+func setIfNotNull(s map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case int:
+		if v != 0 {
+			s[key] = v
+		}
+	case string:
+		if len(v) > 0 {
+			s[key] = v
+		}
+	case *int:
+		if v != nil {
+			s[key] = *v
+		}
+	case *bool:
+		if v != nil {
+			s[key] = *v
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package pipelinesettings
+
+import (
+	"testing"
+
+	"github.com/skysoft-atm/terraform-provider-elastic/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	// Kibana returns null settings when none were specified
+	assert.Equal(t, Defaults(), Flatten(nil))
+	assert.Equal(t, Defaults(), Flatten(&api.Settings{}))
+
+	attributes := Flatten(&api.Settings{PipelineWorkers: 4, QueueCheckpointAcks: api.Int(0), QueueDrain: api.Bool(true)})
+	assert.Equal(t, 4, attributes["workers"])
+	assert.Equal(t, 0, attributes["queue_checkpoint_acks"])
+	assert.Equal(t, true, attributes["queue_drain"])
+	assert.Equal(t, api.DefaultPipelineBatchSize, attributes["batch_size"])
+}
+
+func TestAttributes(t *testing.T) {
+	assert.Empty(t, Attributes(nil))
+
+	settings := &api.Settings{PipelineWorkers: 4, QueueType: "persisted", QueueDrain: api.Bool(true), QueueMaxBytes: api.DefaultQueueMaxBytes}
+	assert.Equal(t, map[string]interface{}{"workers": 4, "queue_type": "persisted", "queue_drain": true}, Attributes(settings))
+}
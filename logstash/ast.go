@@ -0,0 +1,174 @@
+// Package logstash parses the Logstash configuration language used to define pipelines.
+// https://www.elastic.co/guide/en/logstash/current/configuration-file-structure.html
+package logstash
+
+import "fmt"
+
+// Pos is a position in the pipeline definition (both starting at 1)
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// Config is a parsed pipeline definition
+type Config struct {
+	Sections []*Section
+}
+
+// Section is an input, filter or output block
+type Section struct {
+	Pos  Pos
+	Type string
+	Body []Node
+}
+
+// Node is an element of a section body: either a *Plugin or a *Branch
+type Node interface {
+	node()
+}
+
+// Plugin is a plugin block (e.g. beats { port => 5044 }), it can also be used as an attribute value (codecs)
+type Plugin struct {
+	Pos        Pos
+	Name       string
+	Attributes []*Attribute
+}
+
+// Attribute is a plugin setting (name => value)
+type Attribute struct {
+	Pos   Pos
+	Name  string
+	Value Value
+}
+
+// Branch is an if / else if / else chain
+type Branch struct {
+	Pos Pos
+	// Cases holds the if and else if blocks
+	Cases []*Case
+	// Else is nil when there is no else block
+	Else []Node
+}
+
+// Case is an if or else if block
+type Case struct {
+	Pos       Pos
+	Condition *Condition
+	Body      []Node
+}
+
+func (*Plugin) node() {}
+func (*Branch) node() {}
+
+// Value is an attribute value: *String, *Number, *Bareword, *Array, *Hash or *Plugin
+type Value interface {
+	value()
+}
+
+// String is a quoted string, Value holds the raw content between the quotes
+type String struct {
+	Pos   Pos
+	Value string
+	// Quote is the quote character used in the definition (" or ')
+	Quote rune
+}
+
+// Number is an integer or a float, kept as written
+type Number struct {
+	Pos  Pos
+	Text string
+}
+
+// Bareword is an unquoted word (e.g. persisted)
+type Bareword struct {
+	Pos  Pos
+	Text string
+}
+
+// Array is a list of values
+type Array struct {
+	Pos    Pos
+	Values []Value
+}
+
+// Hash is a list of key => value entries
+type Hash struct {
+	Pos     Pos
+	Entries []*HashEntry
+}
+
+// HashEntry is a hash key => value pair, Key is a *String, *Number or *Bareword
+type HashEntry struct {
+	Key   Value
+	Value Value
+}
+
+func (*String) value()   {}
+func (*Number) value()   {}
+func (*Bareword) value() {}
+func (*Array) value()    {}
+func (*Hash) value()     {}
+func (*Plugin) value()   {}
+
+// Condition is a list of expressions joined by boolean operators (and, or, xor, nand),
+// len(Operators) == len(Expressions) - 1
+type Condition struct {
+	Expressions []Expression
+	Operators   []string
+}
+
+// Expression is an element of a condition: *Comparison, *Negation, *Group or an rvalue
+// (*String, *Number, *Selector, *Array, *MethodCall, *Regexp)
+type Expression interface {
+	expression()
+}
+
+// Comparison is a binary expression: ==, !=, <, >, <=, >=, =~, !~, in, not in
+type Comparison struct {
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+// Negation is !(condition) or ![selector]
+type Negation struct {
+	Expression Expression
+}
+
+// Group is a parenthesized condition
+type Group struct {
+	Condition *Condition
+}
+
+// Selector is a field reference (e.g. [kubernetes][labels][name])
+type Selector struct {
+	Pos  Pos
+	Text string
+}
+
+// MethodCall is a function call in a condition (e.g. size([tags]))
+type MethodCall struct {
+	Pos       Pos
+	Name      string
+	Arguments []Expression
+}
+
+// Regexp is a regular expression literal (e.g. /^foo/), Text includes the slashes
+type Regexp struct {
+	Pos  Pos
+	Text string
+}
+
+func (*Comparison) expression() {}
+func (*Negation) expression()   {}
+func (*Group) expression()      {}
+func (*Selector) expression()   {}
+func (*MethodCall) expression() {}
+func (*Regexp) expression()     {}
+func (*String) expression()     {}
+func (*Number) expression()     {}
+func (*Array) expression()      {}
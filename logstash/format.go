@@ -0,0 +1,216 @@
+package logstash
+
+import (
+	"strings"
+)
+
+const indentation = "  "
+
+// Format returns the canonical text of a configuration: two spaces indentation,
+// one plugin attribute per line and no comments
+func Format(c *Config) string {
+	f := &formatter{}
+	f.config(c)
+	return f.String()
+}
+
+// Equivalent reports whether two pipeline definitions have the same structure, ignoring whitespaces,
+// comments and quoting style (barewords, single or double quotes). It is false if either does not parse.
+func Equivalent(a, b string) bool {
+	configA, err := Parse(a)
+	if err != nil {
+		return false
+	}
+	configB, err := Parse(b)
+	if err != nil {
+		return false
+	}
+	f := &formatter{normalize: true}
+	f.config(configA)
+	canonicalA := f.String()
+	f = &formatter{normalize: true}
+	f.config(configB)
+	return canonicalA == f.String()
+}
+
+type formatter struct {
+	strings.Builder
+	depth int
+	// normalize prints barewords as strings, Logstash does not make any difference between them
+	normalize bool
+}
+
+func (f *formatter) line(parts ...string) {
+	f.WriteString(strings.Repeat(indentation, f.depth))
+	for _, part := range parts {
+		f.WriteString(part)
+	}
+	f.WriteString("\n")
+}
+
+func (f *formatter) config(c *Config) {
+	for _, section := range c.Sections {
+		f.line(section.Type, " {")
+		f.depth++
+		f.body(section.Body)
+		f.depth--
+		f.line("}")
+	}
+}
+
+func (f *formatter) body(nodes []Node) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Plugin:
+			f.line(f.plugin(n))
+		case *Branch:
+			f.branch(n)
+		}
+	}
+}
+
+func (f *formatter) branch(b *Branch) {
+	for i, c := range b.Cases {
+		opening := "if "
+		if i > 0 {
+			opening = "} else if "
+		}
+		f.line(opening, f.condition(c.Condition), " {")
+		f.depth++
+		f.body(c.Body)
+		f.depth--
+	}
+	if b.Else != nil {
+		f.line("} else {")
+		f.depth++
+		f.body(b.Else)
+		f.depth--
+	}
+	f.line("}")
+}
+
+// plugin returns the plugin text, nested lines being indented one level deeper than the current depth
+func (f *formatter) plugin(p *Plugin) string {
+	if len(p.Attributes) == 0 {
+		return f.name(p.Name) + " {}"
+	}
+	var b strings.Builder
+	b.WriteString(f.name(p.Name) + " {\n")
+	f.depth++
+	for _, attribute := range p.Attributes {
+		b.WriteString(strings.Repeat(indentation, f.depth) + f.name(attribute.Name) + " => " + f.value(attribute.Value) + "\n")
+	}
+	f.depth--
+	b.WriteString(strings.Repeat(indentation, f.depth) + "}")
+	return b.String()
+}
+
+// name quotes plugin and attribute names which are not valid unquoted names
+func (f *formatter) name(name string) string {
+	if len(name) == 0 {
+		return quote(name)
+	}
+	for _, r := range name {
+		if !isNameChar(r) {
+			return quote(name)
+		}
+	}
+	return name
+}
+
+func (f *formatter) value(v Value) string {
+	switch value := v.(type) {
+	case *String:
+		return quote(value.Value)
+	case *Number:
+		return value.Text
+	case *Bareword:
+		if f.normalize {
+			return quote(value.Text)
+		}
+		return value.Text
+	case *Array:
+		values := make([]string, 0, len(value.Values))
+		for _, element := range value.Values {
+			values = append(values, f.value(element))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case *Hash:
+		if len(value.Entries) == 0 {
+			return "{}"
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		f.depth++
+		for _, entry := range value.Entries {
+			b.WriteString(strings.Repeat(indentation, f.depth) + f.value(entry.Key) + " => " + f.value(entry.Value) + "\n")
+		}
+		f.depth--
+		b.WriteString(strings.Repeat(indentation, f.depth) + "}")
+		return b.String()
+	case *Plugin:
+		return f.plugin(value)
+	}
+	return ""
+}
+
+func (f *formatter) condition(c *Condition) string {
+	var b strings.Builder
+	for i, expression := range c.Expressions {
+		if i > 0 {
+			b.WriteString(" " + c.Operators[i-1] + " ")
+		}
+		b.WriteString(f.expression(expression))
+	}
+	return b.String()
+}
+
+func (f *formatter) expression(e Expression) string {
+	switch expression := e.(type) {
+	case *Comparison:
+		return f.expression(expression.Left) + " " + expression.Operator + " " + f.expression(expression.Right)
+	case *Negation:
+		return "!" + f.expression(expression.Expression)
+	case *Group:
+		return "(" + f.condition(expression.Condition) + ")"
+	case *Selector:
+		return expression.Text
+	case *Regexp:
+		return expression.Text
+	case *MethodCall:
+		arguments := make([]string, 0, len(expression.Arguments))
+		for _, argument := range expression.Arguments {
+			arguments = append(arguments, f.expression(argument))
+		}
+		return expression.Name + "(" + strings.Join(arguments, ", ") + ")"
+	case *String:
+		return quote(expression.Value)
+	case *Number:
+		return expression.Text
+	case *Array:
+		return f.value(expression)
+	}
+	return ""
+}
+
+// quote returns the string between double quotes, unless it contains a double quote
+// which is not escaped (it then comes from a single quoted string).
+// The content is kept as is since Logstash does not interpret escape sequences by default.
+func quote(s string) string {
+	if hasUnescaped(s, '"') {
+		return "'" + s + "'"
+	}
+	return `"` + s + `"`
+}
+
+// hasUnescaped reports whether s contains the quote character without a preceding backslash
+func hasUnescaped(s string, quote rune) bool {
+	previous := rune(0)
+	for _, r := range s {
+		if r == quote && previous != '\\' {
+			return true
+		}
+		previous = r
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package logstash
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	config, err := Parse(`input{beats{port=>5044 codec=>json{charset=>"UTF-8"}}}
+# comment
+filter {
+    if [type]=="syslog" and !([level] in ["debug",'trace']) {
+        grok { match => { "message" => 'say "hi"' } tag_on_failure => [] }
+    } else if [a] =~ /x/ { drop{} }
+    else { mutate { add_field => {} } }
+}
+output { stdout { codec => rubydebug } }`)
+	if !assert.Nil(t, err, "expecting nil error") {
+		return
+	}
+
+	expected := `input {
+  beats {
+    port => 5044
+    codec => json {
+      charset => "UTF-8"
+    }
+  }
+}
+filter {
+  if [type] == "syslog" and !([level] in ["debug", "trace"]) {
+    grok {
+      match => {
+        "message" => 'say "hi"'
+      }
+      tag_on_failure => []
+    }
+  } else if [a] =~ /x/ {
+    drop {}
+  } else {
+    mutate {
+      add_field => {}
+    }
+  }
+}
+output {
+  stdout {
+    codec => rubydebug
+  }
+}
+`
+	assert.Equal(t, expected, Format(config))
+}
+
+func TestFormatIsStable(t *testing.T) {
+	files, err := filepath.Glob("testdata/valid/*.conf")
+	assert.Nil(t, err)
+	files = append(files, "../example/pipeline.conf")
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+		config, err := Parse(string(content))
+		if !assert.Nil(t, err, "[ %s ] expecting nil error", file) {
+			continue
+		}
+		formatted := Format(config)
+		reparsed, err := Parse(formatted)
+		if assert.Nil(t, err, "[ %s ] expecting formatted pipeline to parse", file) {
+			assert.Equal(t, formatted, Format(reparsed), "[ %s ] expecting format to be stable", file)
+		}
+		assert.True(t, Equivalent(string(content), formatted), "[ %s ] expecting formatted pipeline to be equivalent", file)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	reference := `input { beats { port => 5044 } } output { stdout { codec => rubydebug } }`
+	tests := []struct {
+		other      string
+		equivalent bool
+	}{
+		{reference, true},
+		{"input {\n\tbeats {\n\t\tport => 5044\n\t}\n}\n\noutput {\n  stdout { codec => rubydebug }\n}\n", true},
+		{"# Beats input\ninput { beats { port => 5044 } } # end\noutput { stdout { codec => rubydebug } }", true},
+		{`input { beats { port => 5044 } } output { stdout { codec => 'rubydebug' } }`, true},
+		{`input { beats { port => 5044 } } output { stdout { codec => "rubydebug" } }`, true},
+		{`input { beats { port => 5045 } } output { stdout { codec => rubydebug } }`, false},
+		{`input { beats { port => 5044 } } output { stdout { codec => json } }`, false},
+		{`input { beats { port => 5044 } } output { stdout { } }`, false},
+		{`output { stdout { codec => rubydebug } } input { beats { port => 5044 } }`, false},
+		{`input { beats { port => 5044 }`, false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.equivalent, Equivalent(reference, test.other), "[ %s ] unexpected result", test.other)
+	}
+
+	// Escaped quotes are kept by Logstash, so they are not equivalent to single quoted strings
+	assert.True(t, Equivalent(`input { x { s => 'say "hi"' } }`, `input { x { s => 'say "hi"' } }`))
+	assert.False(t, Equivalent(`input { x { s => 'say "hi"' } }`, `input { x { s => "say \"hi\"" } }`))
+}
@@ -0,0 +1,218 @@
+package logstash
+
+import (
+	"sort"
+)
+
+// PipelineAddresses returns the addresses listened to by the pipeline inputs and the send_to addresses of the pipeline
+// outputs (pipeline-to-pipeline communication), in order of appearance
+// https://www.elastic.co/guide/en/logstash/current/pipeline-to-pipeline.html
+func PipelineAddresses(c *Config) (inputs []string, outputs []string) {
+	for _, section := range c.Sections {
+		for _, plugin := range pipelinePlugins(section.Body) {
+			for _, attribute := range plugin.Attributes {
+				switch {
+				case section.Type == "input" && attribute.Name == "address":
+					inputs = append(inputs, scalars(attribute.Value)...)
+				case section.Type == "output" && attribute.Name == "send_to":
+					outputs = append(outputs, scalars(attribute.Value)...)
+				}
+			}
+		}
+	}
+	return inputs, outputs
+}
+
+// pipelinePlugins returns the pipeline plugins of a section body, including the conditional ones
+func pipelinePlugins(nodes []Node) []*Plugin {
+	var plugins []*Plugin
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Plugin:
+			if n.Name == "pipeline" {
+				plugins = append(plugins, n)
+			}
+		case *Branch:
+			for _, c := range n.Cases {
+				plugins = append(plugins, pipelinePlugins(c.Body)...)
+			}
+			plugins = append(plugins, pipelinePlugins(n.Else)...)
+		}
+	}
+	return plugins
+}
+
+// scalars returns the text of a string, bareword or number, or of the elements of an array of them
+func scalars(v Value) []string {
+	switch value := v.(type) {
+	case *String:
+		return []string{value.Value}
+	case *Bareword:
+		return []string{value.Text}
+	case *Number:
+		return []string{value.Text}
+	case *Array:
+		var values []string
+		for _, element := range value.Values {
+			values = append(values, scalars(element)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// PipelineNode is a pipeline with its pipeline-to-pipeline addresses
+type PipelineNode struct {
+	ID      string
+	Inputs  []string
+	Outputs []string
+}
+
+// Edge is a pipeline sending events to another one through an address
+type Edge struct {
+	From    string
+	To      string
+	Address string
+}
+
+// DanglingAddress is a send_to address no pipeline listens to, events sent to it are blocked
+type DanglingAddress struct {
+	Pipeline string
+	Address  string
+}
+
+// DuplicateAddress is an address listened to by several pipeline inputs, Logstash refuses to start them
+type DuplicateAddress struct {
+	Address   string
+	Pipelines []string
+}
+
+// Graph is the pipeline-to-pipeline communication graph, all the lists are sorted
+type Graph struct {
+	Edges      []Edge
+	Dangling   []DanglingAddress
+	Duplicates []DuplicateAddress
+	// Cycles holds the groups of pipelines sending events to each other (strongly connected components)
+	Cycles [][]string
+}
+
+// NewGraph analyzes the communication between pipelines
+func NewGraph(nodes []PipelineNode) *Graph {
+	sorted := make([]PipelineNode, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	g := &Graph{}
+	listeners := make(map[string][]string)
+	for _, node := range sorted {
+		for _, address := range node.Inputs {
+			listeners[address] = append(listeners[address], node.ID)
+		}
+	}
+
+	addresses := make([]string, 0, len(listeners))
+	for address := range listeners {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		if len(listeners[address]) > 1 {
+			g.Duplicates = append(g.Duplicates, DuplicateAddress{Address: address, Pipelines: listeners[address]})
+		}
+	}
+
+	successors := make(map[string][]string)
+	for _, node := range sorted {
+		for _, address := range node.Outputs {
+			targets, ok := listeners[address]
+			if !ok {
+				g.Dangling = append(g.Dangling, DanglingAddress{Pipeline: node.ID, Address: address})
+				continue
+			}
+			for _, target := range targets {
+				g.Edges = append(g.Edges, Edge{From: node.ID, To: target, Address: address})
+				successors[node.ID] = append(successors[node.ID], target)
+			}
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Address < b.Address
+	})
+	sort.SliceStable(g.Dangling, func(i, j int) bool { return g.Dangling[i].Pipeline < g.Dangling[j].Pipeline })
+
+	g.Cycles = cycles(sorted, successors)
+	return g
+}
+
+// cycles returns the strongly connected components involving more than one pipeline, or a pipeline
+// sending events to itself (Tarjan's algorithm)
+func cycles(nodes []PipelineNode, successors map[string][]string) [][]string {
+	index := 0
+	indexes := make(map[string]int)
+	lowlinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(id string)
+	connect = func(id string) {
+		indexes[id] = index
+		lowlinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, successor := range successors[id] {
+			if _, visited := indexes[successor]; !visited {
+				connect(successor)
+				if lowlinks[successor] < lowlinks[id] {
+					lowlinks[id] = lowlinks[successor]
+				}
+			} else if onStack[successor] && indexes[successor] < lowlinks[id] {
+				lowlinks[id] = indexes[successor]
+			}
+		}
+
+		if lowlinks[id] != indexes[id] {
+			return
+		}
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == id {
+				break
+			}
+		}
+		if len(component) > 1 || sendsToItself(id, successors) {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := indexes[node.ID]; !visited {
+			connect(node.ID)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+func sendsToItself(id string, successors map[string][]string) bool {
+	for _, successor := range successors[id] {
+		if successor == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package logstash
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineAddresses(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/valid/pipeline-to-pipeline.conf")
+	if !assert.Nil(t, err, "unable to read file") {
+		return
+	}
+	config, err := Parse(string(content))
+	if !assert.Nil(t, err, "expecting nil error") {
+		return
+	}
+	inputs, outputs := PipelineAddresses(config)
+	assert.Empty(t, inputs)
+	assert.Equal(t, []string{"weblogs", "syslog", "archive", "fallback"}, outputs)
+
+	config, err = Parse(`
+input {
+  pipeline { address => "weblogs" }
+  beats { address => "not-a-pipeline" }
+}
+output {
+  pipeline { send_to => ["archive"] }
+  elasticsearch { send_to => "not-a-pipeline" }
+}`)
+	if !assert.Nil(t, err, "expecting nil error") {
+		return
+	}
+	inputs, outputs = PipelineAddresses(config)
+	assert.Equal(t, []string{"weblogs"}, inputs)
+	assert.Equal(t, []string{"archive"}, outputs)
+}
+
+func TestNewGraph(t *testing.T) {
+	g := NewGraph([]PipelineNode{
+		{ID: "distributor", Outputs: []string{"weblogs", "syslog", "unknown"}},
+		{ID: "weblogs", Inputs: []string{"weblogs"}, Outputs: []string{"archive"}},
+		{ID: "syslog", Inputs: []string{"syslog"}, Outputs: []string{"archive"}},
+		{ID: "archive", Inputs: []string{"archive"}},
+		{ID: "archive-copy", Inputs: []string{"archive"}},
+		// Loops
+		{ID: "ping", Inputs: []string{"ping"}, Outputs: []string{"pong"}},
+		{ID: "pong", Inputs: []string{"pong"}, Outputs: []string{"ping"}},
+		{ID: "self", Inputs: []string{"self"}, Outputs: []string{"self"}},
+	})
+
+	assert.Equal(t, []Edge{
+		{From: "distributor", To: "syslog", Address: "syslog"},
+		{From: "distributor", To: "weblogs", Address: "weblogs"},
+		{From: "ping", To: "pong", Address: "pong"},
+		{From: "pong", To: "ping", Address: "ping"},
+		{From: "self", To: "self", Address: "self"},
+		{From: "syslog", To: "archive", Address: "archive"},
+		{From: "syslog", To: "archive-copy", Address: "archive"},
+		{From: "weblogs", To: "archive", Address: "archive"},
+		{From: "weblogs", To: "archive-copy", Address: "archive"},
+	}, g.Edges)
+	assert.Equal(t, []DanglingAddress{{Pipeline: "distributor", Address: "unknown"}}, g.Dangling)
+	assert.Equal(t, []DuplicateAddress{{Address: "archive", Pipelines: []string{"archive", "archive-copy"}}}, g.Duplicates)
+	assert.Equal(t, [][]string{{"ping", "pong"}, {"self"}}, g.Cycles)
+}
+
+func TestNewGraphWithoutIssues(t *testing.T) {
+	g := NewGraph([]PipelineNode{
+		{ID: "a", Outputs: []string{"b"}},
+		{ID: "b", Inputs: []string{"b"}, Outputs: []string{"c"}},
+		{ID: "c", Inputs: []string{"c"}},
+	})
+	assert.Len(t, g.Edges, 2)
+	assert.Empty(t, g.Dangling)
+	assert.Empty(t, g.Duplicates)
+	assert.Empty(t, g.Cycles)
+}
@@ -0,0 +1,791 @@
+package logstash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError reports an invalid pipeline definition with its position
+type SyntaxError struct {
+	Pos     Pos
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// sectionTypes are the plugin sections allowed at the root of a pipeline
+var sectionTypes = []string{"input", "filter", "output"}
+
+// booleanOperators join expressions in conditions
+var booleanOperators = []string{"and", "or", "xor", "nand"}
+
+// compareOperators are ordered so that the longest operators are matched first
+var compareOperators = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// Parse parses a pipeline definition, following the grammar of Logstash
+// https://github.com/elastic/logstash/blob/master/logstash-core/lib/logstash/compiler/lscl/lscl_grammar.treetop
+func Parse(definition string) (*Config, error) {
+	p := &parser{input: []rune(definition), line: 1, column: 1}
+	return p.parseConfig()
+}
+
+// ParseCondition parses the condition of an if or else if block (e.g. [type] == "syslog")
+func ParseCondition(condition string) (*Condition, error) {
+	p := &parser{input: []rune(condition), line: 1, column: 1}
+	p.skip()
+	c, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	return c, p.expectEOF()
+}
+
+// ParseValue parses an attribute value (e.g. 5044, [ "a", "b" ] or { "key" => "value" })
+func ParseValue(value string) (Value, error) {
+	p := &parser{input: []rune(value), line: 1, column: 1}
+	p.skip()
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return v, p.expectEOF()
+}
+
+type parser struct {
+	input  []rune
+	offset int
+	line   int
+	column int
+}
+
+// state allows to backtrack
+type state struct {
+	offset, line, column int
+}
+
+func (p *parser) save() state {
+	return state{p.offset, p.line, p.column}
+}
+
+func (p *parser) restore(s state) {
+	p.offset, p.line, p.column = s.offset, s.line, s.column
+}
+
+func (p *parser) pos() Pos {
+	return Pos{Line: p.line, Column: p.column}
+}
+
+func (p *parser) eof() bool {
+	return p.offset >= len(p.input)
+}
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.offset]
+}
+
+func (p *parser) peekAt(n int) rune {
+	if p.offset+n >= len(p.input) {
+		return 0
+	}
+	return p.input[p.offset+n]
+}
+
+func (p *parser) next() rune {
+	r := p.input[p.offset]
+	p.offset++
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+// expectEOF checks that nothing but whitespaces and comments remain
+func (p *parser) expectEOF() error {
+	p.skip()
+	if !p.eof() {
+		return p.errorf(p.pos(), "unexpected %s", p.unexpected())
+	}
+	return nil
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// unexpected describes the current character for error messages
+func (p *parser) unexpected() string {
+	if p.eof() {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", p.peek())
+}
+
+// skip consumes whitespaces and comments
+func (p *parser) skip() {
+	for !p.eof() {
+		switch r := p.peek(); {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			p.next()
+		case r == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// hasPrefix reports whether the input at the current offset starts with s
+func (p *parser) hasPrefix(s string) bool {
+	for i, r := range []rune(s) {
+		if p.peekAt(i) != r {
+			return false
+		}
+	}
+	return true
+}
+
+// hasKeyword reports whether the input at the current offset is the keyword (not followed by a name character)
+func (p *parser) hasKeyword(keyword string) bool {
+	return p.hasPrefix(keyword) && !isNameChar(p.peekAt(len([]rune(keyword))))
+}
+
+func (p *parser) consume(s string) {
+	for range []rune(s) {
+		p.next()
+	}
+}
+
+func (p *parser) expect(s string) error {
+	if !p.hasPrefix(s) {
+		return p.errorf(p.pos(), "expected %q, got %s", s, p.unexpected())
+	}
+	p.consume(s)
+	return nil
+}
+
+func isNameChar(r rune) bool {
+	return r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func isBarewordStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isBarewordChar(r rune) bool {
+	return isBarewordStart(r) || (r >= '0' && r <= '9')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func (p *parser) parseConfig() (*Config, error) {
+	config := &Config{}
+	p.skip()
+	for !p.eof() {
+		section, err := p.parseSection()
+		if err != nil {
+			return nil, err
+		}
+		config.Sections = append(config.Sections, section)
+		p.skip()
+	}
+	if len(config.Sections) == 0 {
+		return nil, p.errorf(p.pos(), "expected at least one of %s sections", strings.Join(sectionTypes, ", "))
+	}
+	return config, nil
+}
+
+func (p *parser) parseSection() (*Section, error) {
+	pos := p.pos()
+	name := p.scanName()
+	valid := false
+	for _, t := range sectionTypes {
+		if name == t {
+			valid = true
+		}
+	}
+	if !valid {
+		if len(name) == 0 {
+			return nil, p.errorf(pos, "expected one of %s sections, got %s", strings.Join(sectionTypes, ", "), p.unexpected())
+		}
+		return nil, p.errorf(pos, "expected one of %s sections, got %q", strings.Join(sectionTypes, ", "), name)
+	}
+	p.skip()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	body, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	return &Section{Pos: pos, Type: name, Body: body}, nil
+}
+
+// parseBody parses plugins and branches until the closing brace (consumed)
+func (p *parser) parseBody() ([]Node, error) {
+	var body []Node
+	for {
+		p.skip()
+		if p.eof() {
+			return nil, p.errorf(p.pos(), "expected \"}\", got end of input")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return body, nil
+		}
+		var node Node
+		var err error
+		if p.hasKeyword("if") {
+			node, err = p.parseBranch()
+		} else {
+			node, err = p.parsePlugin()
+		}
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, node)
+	}
+}
+
+func (p *parser) parseBranch() (*Branch, error) {
+	branch := &Branch{Pos: p.pos()}
+	p.consume("if")
+	c, err := p.parseCase(branch.Pos)
+	if err != nil {
+		return nil, err
+	}
+	branch.Cases = append(branch.Cases, c)
+
+	for {
+		s := p.save()
+		p.skip()
+		if !p.hasKeyword("else") {
+			p.restore(s)
+			return branch, nil
+		}
+		pos := p.pos()
+		p.consume("else")
+		p.skip()
+		if p.hasKeyword("if") {
+			p.consume("if")
+			c, err := p.parseCase(pos)
+			if err != nil {
+				return nil, err
+			}
+			branch.Cases = append(branch.Cases, c)
+			continue
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		body, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+		branch.Else = body
+		return branch, nil
+	}
+}
+
+// parseCase parses the condition and the body following an if / else if keyword
+func (p *parser) parseCase(pos Pos) (*Case, error) {
+	p.skip()
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	body, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	return &Case{Pos: pos, Condition: condition, Body: body}, nil
+}
+
+// scanName consumes a plugin or attribute name ([A-Za-z0-9_-]+), empty if there is none
+func (p *parser) scanName() string {
+	start := p.offset
+	for !p.eof() && isNameChar(p.peek()) {
+		p.next()
+	}
+	return string(p.input[start:p.offset])
+}
+
+// parseName parses a name, either unquoted or as a string
+func (p *parser) parseName() (string, error) {
+	if r := p.peek(); r == '"' || r == '\'' {
+		s, err := p.parseString()
+		if err != nil {
+			return "", err
+		}
+		return s.Value, nil
+	}
+	pos := p.pos()
+	name := p.scanName()
+	if len(name) == 0 {
+		return "", p.errorf(pos, "expected a name, got %s", p.unexpected())
+	}
+	return name, nil
+}
+
+func (p *parser) parsePlugin() (*Plugin, error) {
+	pos := p.pos()
+	name, err := p.parseName()
+	if err != nil {
+		return nil, p.errorf(pos, "expected a plugin, got %s", p.unexpected())
+	}
+	p.skip()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	return p.parsePluginBody(pos, name)
+}
+
+// parsePluginBody parses the attributes of a plugin, after the opening brace
+func (p *parser) parsePluginBody(pos Pos, name string) (*Plugin, error) {
+	plugin := &Plugin{Pos: pos, Name: name}
+	for {
+		p.skip()
+		if p.eof() {
+			return nil, p.errorf(p.pos(), "expected \"}\" to close plugin %q, got end of input", name)
+		}
+		if p.peek() == '}' {
+			p.next()
+			return plugin, nil
+		}
+		attribute, err := p.parseAttribute()
+		if err != nil {
+			return nil, err
+		}
+		plugin.Attributes = append(plugin.Attributes, attribute)
+	}
+}
+
+func (p *parser) parseAttribute() (*Attribute, error) {
+	pos := p.pos()
+	name, err := p.parseName()
+	if err != nil {
+		return nil, p.errorf(pos, "expected an attribute or \"}\", got %s", p.unexpected())
+	}
+	p.skip()
+	if err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	p.skip()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &Attribute{Pos: pos, Name: name, Value: value}, nil
+}
+
+// parseValue parses an attribute value: plugin, bareword, string, number, array or hash
+func (p *parser) parseValue() (Value, error) {
+	pos := p.pos()
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '[':
+		return p.parseArray()
+	case r == '{':
+		return p.parseHash()
+	case r == '-' || isDigit(r):
+		// Plugin names can start with a digit, numbers are more likely
+		s := p.save()
+		if name := p.scanName(); len(name) > 0 {
+			p.skip()
+			if p.peek() == '{' {
+				p.next()
+				return p.parsePluginBody(pos, name)
+			}
+		}
+		p.restore(s)
+		return p.parseNumber()
+	case isNameChar(r):
+		name := p.scanName()
+		s := p.save()
+		p.skip()
+		if p.peek() == '{' {
+			p.next()
+			return p.parsePluginBody(pos, name)
+		}
+		p.restore(s)
+		if !isBareword(name) {
+			return nil, p.errorf(pos, "invalid bareword %q, quote it", name)
+		}
+		return &Bareword{Pos: pos, Text: name}, nil
+	}
+	return nil, p.errorf(pos, "expected a value, got %s", p.unexpected())
+}
+
+// isBareword checks [A-Za-z_][A-Za-z0-9_]+
+func isBareword(s string) bool {
+	runes := []rune(s)
+	if len(runes) < 2 || !isBarewordStart(runes[0]) {
+		return false
+	}
+	for _, r := range runes[1:] {
+		if !isBarewordChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseString parses a single or double quoted string, a backslash only escapes the quote character
+func (p *parser) parseString() (*String, error) {
+	pos := p.pos()
+	quote := p.next()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return nil, p.errorf(pos, "unterminated string")
+		}
+		r := p.next()
+		if r == '\\' && p.peek() == quote {
+			b.WriteRune(r)
+			b.WriteRune(p.next())
+			continue
+		}
+		if r == quote {
+			return &String{Pos: pos, Value: b.String(), Quote: quote}, nil
+		}
+		b.WriteRune(r)
+	}
+}
+
+// parseNumber parses -?[0-9]+(\.[0-9]*)?
+func (p *parser) parseNumber() (*Number, error) {
+	pos := p.pos()
+	start := p.offset
+	if p.peek() == '-' {
+		p.next()
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.errorf(p.pos(), "expected a digit, got %s", p.unexpected())
+	}
+	for isDigit(p.peek()) {
+		p.next()
+	}
+	if p.peek() == '.' {
+		p.next()
+		for isDigit(p.peek()) {
+			p.next()
+		}
+	}
+	return &Number{Pos: pos, Text: string(p.input[start:p.offset])}, nil
+}
+
+func (p *parser) parseArray() (*Array, error) {
+	array := &Array{Pos: p.pos()}
+	p.next()
+	p.skip()
+	if p.peek() == ']' {
+		p.next()
+		return array, nil
+	}
+	for {
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array.Values = append(array.Values, value)
+		p.skip()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+			p.next()
+			return array, nil
+		default:
+			return nil, p.errorf(p.pos(), "expected \",\" or \"]\" in array, got %s", p.unexpected())
+		}
+	}
+}
+
+func (p *parser) parseHash() (*Hash, error) {
+	hash := &Hash{Pos: p.pos()}
+	p.next()
+	for {
+		p.skip()
+		if p.eof() {
+			return nil, p.errorf(p.pos(), "expected \"}\" to close hash, got end of input")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return hash, nil
+		}
+		key, err := p.parseHashKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skip()
+		if err := p.expect("=>"); err != nil {
+			return nil, err
+		}
+		p.skip()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		hash.Entries = append(hash.Entries, &HashEntry{Key: key, Value: value})
+	}
+}
+
+// parseHashKey parses a number, a bareword or a string
+func (p *parser) parseHashKey() (Value, error) {
+	pos := p.pos()
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '-' || isDigit(r):
+		return p.parseNumber()
+	case isBarewordStart(r):
+		name := p.scanName()
+		if !isBareword(name) {
+			return nil, p.errorf(pos, "invalid hash key %q, quote it", name)
+		}
+		return &Bareword{Pos: pos, Text: name}, nil
+	}
+	return nil, p.errorf(pos, "expected a hash key or \"}\", got %s", p.unexpected())
+}
+
+// parseCondition parses expressions joined by boolean operators
+func (p *parser) parseCondition() (*Condition, error) {
+	condition := &Condition{}
+	for {
+		expression, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		condition.Expressions = append(condition.Expressions, expression)
+
+		s := p.save()
+		p.skip()
+		operator := ""
+		for _, op := range booleanOperators {
+			if p.hasKeyword(op) {
+				operator = op
+			}
+		}
+		if len(operator) == 0 {
+			p.restore(s)
+			return condition, nil
+		}
+		p.consume(operator)
+		condition.Operators = append(condition.Operators, operator)
+		p.skip()
+	}
+}
+
+func (p *parser) parseExpression() (Expression, error) {
+	switch p.peek() {
+	case '(':
+		return p.parseGroup()
+	case '!':
+		if p.peekAt(1) != '=' && p.peekAt(1) != '~' {
+			p.next()
+			p.skip()
+			if p.peek() == '(' {
+				group, err := p.parseGroup()
+				if err != nil {
+					return nil, err
+				}
+				return &Negation{Expression: group}, nil
+			}
+			selector, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			return &Negation{Expression: selector}, nil
+		}
+	}
+
+	left, err := p.parseRvalue()
+	if err != nil {
+		return nil, err
+	}
+
+	s := p.save()
+	p.skip()
+	operator := ""
+	switch {
+	case p.hasKeyword("in"):
+		operator = "in"
+		p.consume("in")
+	case p.hasKeyword("not"):
+		p.consume("not")
+		p.skip()
+		if !p.hasKeyword("in") {
+			return nil, p.errorf(p.pos(), "expected \"not in\", got %s", p.unexpected())
+		}
+		p.consume("in")
+		operator = "not in"
+	default:
+		for _, op := range compareOperators {
+			if p.hasPrefix(op) {
+				operator = op
+				p.consume(op)
+				break
+			}
+		}
+	}
+	if len(operator) == 0 {
+		p.restore(s)
+		return left, nil
+	}
+
+	p.skip()
+	var right Expression
+	if operator == "=~" || operator == "!~" {
+		switch p.peek() {
+		case '"', '\'':
+			right, err = p.parseString()
+		case '/':
+			right, err = p.parseRegexp()
+		default:
+			err = p.errorf(p.pos(), "expected a string or a regexp after %q, got %s", operator, p.unexpected())
+		}
+	} else {
+		right, err = p.parseRvalue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Left: left, Operator: operator, Right: right}, nil
+}
+
+func (p *parser) parseGroup() (*Group, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	p.skip()
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &Group{Condition: condition}, nil
+}
+
+// parseRvalue parses a string, number, selector, array, method call or regexp
+func (p *parser) parseRvalue() (Expression, error) {
+	pos := p.pos()
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '-' || isDigit(r):
+		return p.parseNumber()
+	case r == '[':
+		// [foo] is a selector, arrays need several elements or an empty content
+		s := p.save()
+		if selector, err := p.parseSelector(); err == nil {
+			return selector, nil
+		}
+		p.restore(s)
+		return p.parseArray()
+	case r == '/':
+		return p.parseRegexp()
+	case isBarewordStart(r):
+		return p.parseMethodCall()
+	}
+	return nil, p.errorf(pos, "expected a value in condition, got %s", p.unexpected())
+}
+
+// parseSelector parses one or more [field] elements
+func (p *parser) parseSelector() (*Selector, error) {
+	pos := p.pos()
+	start := p.offset
+	for p.peek() == '[' {
+		elementPos := p.pos()
+		p.next()
+		length := 0
+		for !p.eof() && p.peek() != ']' && p.peek() != '[' && p.peek() != ',' {
+			p.next()
+			length++
+		}
+		if length == 0 || p.peek() != ']' {
+			return nil, p.errorf(elementPos, "invalid field reference")
+		}
+		p.next()
+	}
+	if p.offset == start {
+		return nil, p.errorf(pos, "expected a field reference, got %s", p.unexpected())
+	}
+	return &Selector{Pos: pos, Text: string(p.input[start:p.offset])}, nil
+}
+
+func (p *parser) parseMethodCall() (*MethodCall, error) {
+	pos := p.pos()
+	name := p.scanName()
+	if !isBareword(name) {
+		return nil, p.errorf(pos, "unexpected %q in condition", name)
+	}
+	call := &MethodCall{Pos: pos, Name: name}
+	p.skip()
+	if p.peek() != '(' {
+		return nil, p.errorf(pos, "unexpected %q in condition", name)
+	}
+	p.next()
+	p.skip()
+	if p.peek() == ')' {
+		p.next()
+		return call, nil
+	}
+	for {
+		p.skip()
+		argument, err := p.parseRvalue()
+		if err != nil {
+			return nil, err
+		}
+		call.Arguments = append(call.Arguments, argument)
+		p.skip()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ')':
+			p.next()
+			return call, nil
+		default:
+			return nil, p.errorf(p.pos(), "expected \",\" or \")\" in call to %s, got %s", name, p.unexpected())
+		}
+	}
+}
+
+// parseRegexp parses /.../, a backslash only escapes the slash
+func (p *parser) parseRegexp() (*Regexp, error) {
+	pos := p.pos()
+	start := p.offset
+	p.next()
+	for {
+		if p.eof() {
+			return nil, p.errorf(pos, "unterminated regexp")
+		}
+		r := p.next()
+		if r == '\\' && p.peek() == '/' {
+			p.next()
+			continue
+		}
+		if r == '/' {
+			return &Regexp{Pos: pos, Text: string(p.input[start:p.offset])}, nil
+		}
+	}
+}
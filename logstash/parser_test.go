@@ -0,0 +1,174 @@
+package logstash
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCorpus(t *testing.T) {
+	files, err := filepath.Glob("testdata/valid/*.conf")
+	assert.Nil(t, err)
+	files = append(files, "../example/pipeline.conf")
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if assert.Nil(t, err, "[ %s ] unable to read file", file) {
+			_, err = Parse(string(content))
+			assert.Nil(t, err, "[ %s ] expecting nil error", file)
+		}
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	config, err := Parse(`
+input {
+  beats { port => 5044 }
+}
+filter {
+  if [type] == "syslog" and [level] !~ /debug/ {
+    grok { match => { "message" => 'it\'s %{DATA:msg}' } }
+  } else if "x" in [tags] {
+    drop {}
+  } else {
+    mutate { add_tag => [ "other", 1.5 ] codec => json { charset => UTF8 } }
+  }
+}
+output { stdout {} }
+`)
+	if !assert.Nil(t, err, "expecting nil error") {
+		return
+	}
+	if !assert.Len(t, config.Sections, 3) {
+		return
+	}
+	assert.Equal(t, "input", config.Sections[0].Type)
+	assert.Equal(t, Pos{Line: 2, Column: 1}, config.Sections[0].Pos)
+
+	beats := config.Sections[0].Body[0].(*Plugin)
+	assert.Equal(t, "beats", beats.Name)
+	assert.Equal(t, "port", beats.Attributes[0].Name)
+	assert.Equal(t, "5044", beats.Attributes[0].Value.(*Number).Text)
+
+	branch := config.Sections[1].Body[0].(*Branch)
+	assert.Len(t, branch.Cases, 2)
+	assert.NotNil(t, branch.Else)
+
+	condition := branch.Cases[0].Condition
+	assert.Equal(t, []string{"and"}, condition.Operators)
+	first := condition.Expressions[0].(*Comparison)
+	assert.Equal(t, "[type]", first.Left.(*Selector).Text)
+	assert.Equal(t, "==", first.Operator)
+	assert.Equal(t, "syslog", first.Right.(*String).Value)
+	second := condition.Expressions[1].(*Comparison)
+	assert.Equal(t, "!~", second.Operator)
+	assert.Equal(t, "/debug/", second.Right.(*Regexp).Text)
+
+	in := branch.Cases[1].Condition.Expressions[0].(*Comparison)
+	assert.Equal(t, "in", in.Operator)
+
+	mutate := branch.Else[0].(*Plugin)
+	tags := mutate.Attributes[0].Value.(*Array)
+	assert.Equal(t, "other", tags.Values[0].(*String).Value)
+	assert.Equal(t, "1.5", tags.Values[1].(*Number).Text)
+	codec := mutate.Attributes[1].Value.(*Plugin)
+	assert.Equal(t, "json", codec.Name)
+	assert.Equal(t, "UTF8", codec.Attributes[0].Value.(*Bareword).Text)
+}
+
+func TestParseStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		quote    rune
+	}{
+		{`"simple"`, `simple`, '"'},
+		{`'single'`, `single`, '\''},
+		{`"with \"escaped\" quotes"`, `with \"escaped\" quotes`, '"'},
+		{`'it\'s'`, `it\'s`, '\''},
+		{`"back\slash \n kept"`, `back\slash \n kept`, '"'},
+		{`"multi
+line"`, "multi\nline", '"'},
+		{`"%{+yyyy.MM.dd} ${VAR}"`, `%{+yyyy.MM.dd} ${VAR}`, '"'},
+	}
+
+	for _, test := range tests {
+		config, err := Parse("input { x { s => " + test.input + " } }")
+		if assert.Nil(t, err, "[ %s ] expecting nil error", test.input) {
+			s := config.Sections[0].Body[0].(*Plugin).Attributes[0].Value.(*String)
+			assert.Equal(t, test.expected, s.Value, "[ %s ] unexpected value", test.input)
+			assert.Equal(t, test.quote, s.Quote, "[ %s ] unexpected quote", test.input)
+		}
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	_, err := Parse(`# leading comment
+input { # after brace
+  stdin { # in plugin
+    # alone
+    codec => line # after value
+  }
+} # trailing`)
+	assert.Nil(t, err, "expecting comments to be ignored")
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{``, `line 1, column 1: expected at least one of input, filter, output sections`},
+		{`# only a comment`, `line 1, column 17: expected at least one of input, filter, output sections`},
+		{`inputs { }`, `line 1, column 1: expected one of input, filter, output sections, got "inputs"`},
+		{`input {`, `line 1, column 8: expected "}", got end of input`},
+		{"input {\n  stdin {\n    codec => line\n  \n}", `line 5, column 2: expected "}", got end of input`},
+		{"input {\n  beats {\n    port => 5044\n", `line 4, column 1: expected "}" to close plugin "beats", got end of input`},
+		{"input {\n  beats {\n    port 5044\n  }\n}", `line 3, column 10: expected "=>", got '5'`},
+		{"input { beats { port => } }", `line 1, column 25: expected a value, got '}'`},
+		{"input { beats { host => \"unterminated } }", `line 1, column 25: unterminated string`},
+		{"input { beats { hosts => [\"a\" \"b\"] } }", `line 1, column 31: expected "," or "]" in array, got '"'`},
+		{"input { beats { codec => x } }", `line 1, column 26: invalid bareword "x", quote it`},
+		{"input { beats { match => { \"a\" \"b\" } } }", `line 1, column 32: expected "=>", got '"'`},
+		{"filter {\n  if [type] = \"x\" {\n  }\n}", `line 2, column 13: expected "{", got '='`},
+		{"filter {\n  if [type] == {\n  }\n}", `line 2, column 16: expected a value in condition, got '{'`},
+		{"filter {\n  if [a] =~ 5 { }\n}", `line 2, column 13: expected a string or a regexp after "=~", got '5'`},
+		{"filter {\n  if ([a] == 1 { }\n}", `line 2, column 16: expected ")", got '{'`},
+		{"filter {\n  if [a] not [b] { }\n}", `line 2, column 14: expected "not in", got '['`},
+		{"filter { if [a] { } else }", `line 1, column 26: expected "{", got '}'`},
+		{"filter { if [a] =~ /unterminated { } }", `line 1, column 20: unterminated regexp`},
+		{"output { stdout {} } garbage", `line 1, column 22: expected one of input, filter, output sections, got "garbage"`},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.input)
+		if assert.Error(t, err, "[ %s ] expecting error", test.input) {
+			assert.Equal(t, test.expected, err.Error(), "[ %s ] unexpected error", test.input)
+			_, ok := err.(*SyntaxError)
+			assert.True(t, ok, "[ %s ] expecting *SyntaxError", test.input)
+		}
+	}
+}
+
+func TestParseConditionAndValue(t *testing.T) {
+	condition, err := ParseCondition(` [type] == "syslog" or ![tags] `)
+	if assert.Nil(t, err, "expecting nil error") {
+		assert.Len(t, condition.Expressions, 2)
+		assert.Equal(t, []string{"or"}, condition.Operators)
+	}
+	_, err = ParseCondition(`[type] == "syslog" {`)
+	assert.EqualError(t, err, `line 1, column 20: unexpected '{'`)
+
+	value, err := ParseValue(`[ "message", 1 ] # comment`)
+	if assert.Nil(t, err, "expecting nil error") {
+		assert.Len(t, value.(*Array).Values, 2)
+	}
+	value, err = ParseValue(`json { charset => "UTF-8" }`)
+	if assert.Nil(t, err, "expecting nil error") {
+		assert.Equal(t, "json", value.(*Plugin).Name)
+	}
+	_, err = ParseValue(`"a" "b"`)
+	assert.EqualError(t, err, `line 1, column 5: unexpected '"'`)
+}
@@ -0,0 +1,121 @@
+package logstash
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sensitiveOptions are plugin options known to hold credentials, options ending with password or passphrase are sensitive as well
+// (e.g. ssl_key_passphrase, ssl_keystore_password). ssl_key, ssl_certificate or keystore options are not: their value is the
+// path of a file on the Logstash host, not the key material.
+var sensitiveOptions = map[string]bool{
+	"api_key":           true,
+	"cloud_auth":        true,
+	"secret_access_key": true,
+	"session_token":     true,
+	"sasl_jaas_config":  true,
+	"token":             true,
+}
+
+// reference matches environment variable and keystore references (e.g. ${CLOUD_AUTH} or ${ES_PWD:default})
+var reference = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// Secret is a sensitive plugin option with a literal value
+type Secret struct {
+	Pos    Pos
+	Plugin string
+	Option string
+}
+
+func (s Secret) String() string {
+	return fmt.Sprintf("%s: %s of plugin %s has a literal value, use a Logstash keystore or environment variable reference instead (e.g. %s => \"${%s}\")",
+		s.Pos, s.Option, s.Plugin, s.Option, strings.ToUpper(s.Option))
+}
+
+// IsSensitiveOption reports whether the plugin option is known to hold credentials
+func IsSensitiveOption(name string) bool {
+	name = strings.ToLower(name)
+	return sensitiveOptions[name] || strings.HasSuffix(name, "password") || strings.HasSuffix(name, "passphrase")
+}
+
+// FindSecrets returns the sensitive options (including nested codecs and hashes) whose value does not reference
+// any environment variable or keystore entry
+func FindSecrets(c *Config) []Secret {
+	var secrets []Secret
+	for _, section := range c.Sections {
+		secrets = append(secrets, findSecretsInBody(section.Body)...)
+	}
+	return secrets
+}
+
+func findSecretsInBody(nodes []Node) []Secret {
+	var secrets []Secret
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Plugin:
+			secrets = append(secrets, findSecretsInPlugin(n)...)
+		case *Branch:
+			for _, c := range n.Cases {
+				secrets = append(secrets, findSecretsInBody(c.Body)...)
+			}
+			secrets = append(secrets, findSecretsInBody(n.Else)...)
+		}
+	}
+	return secrets
+}
+
+func findSecretsInPlugin(p *Plugin) []Secret {
+	var secrets []Secret
+	for _, attribute := range p.Attributes {
+		if IsSensitiveOption(attribute.Name) && isLiteral(attribute.Value) {
+			secrets = append(secrets, Secret{Pos: attribute.Pos, Plugin: p.Name, Option: attribute.Name})
+		}
+		secrets = append(secrets, findSecretsInValue(p.Name, attribute.Value)...)
+	}
+	return secrets
+}
+
+// findSecretsInValue looks for sensitive keys in hashes (e.g. http_poller urls) and for sensitive options of codecs
+func findSecretsInValue(plugin string, v Value) []Secret {
+	var secrets []Secret
+	switch value := v.(type) {
+	case *Plugin:
+		secrets = append(secrets, findSecretsInPlugin(value)...)
+	case *Array:
+		for _, element := range value.Values {
+			secrets = append(secrets, findSecretsInValue(plugin, element)...)
+		}
+	case *Hash:
+		for _, entry := range value.Entries {
+			key, pos := "", Pos{}
+			switch k := entry.Key.(type) {
+			case *String:
+				key, pos = k.Value, k.Pos
+			case *Bareword:
+				key, pos = k.Text, k.Pos
+			}
+			if IsSensitiveOption(key) && isLiteral(entry.Value) {
+				secrets = append(secrets, Secret{Pos: pos, Plugin: plugin, Option: key})
+			}
+			secrets = append(secrets, findSecretsInValue(plugin, entry.Value)...)
+		}
+	}
+	return secrets
+}
+
+// isLiteral reports whether the value is a non empty scalar without any ${VAR} reference
+func isLiteral(v Value) bool {
+	text := ""
+	switch value := v.(type) {
+	case *String:
+		text = value.Value
+	case *Bareword:
+		text = value.Text
+	case *Number:
+		text = value.Text
+	default:
+		return false
+	}
+	return len(text) > 0 && !reference.MatchString(text)
+}
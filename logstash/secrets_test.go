@@ -0,0 +1,56 @@
+package logstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindSecrets(t *testing.T) {
+	config, err := Parse(`
+input {
+  http_poller {
+    urls => { api => { url => "http://localhost" auth => { user => "elastic" password => "changeme" } } }
+    codec => json { ssl_key_passphrase => "literal" }
+  }
+}
+filter {
+  if [type] == "x" {
+    elasticsearch { password => "${ES_PWD}" api_key => "${ID}:${KEY}" }
+  } else {
+    http { user_password => secret }
+  }
+}
+output {
+  elasticsearch {
+    cloud_id => "my-deployment"
+    cloud_auth => "elastic:changeme"
+    ssl_keystore_password => ""
+    user => "elastic"
+  }
+}
+`)
+	if !assert.Nil(t, err, "expecting nil error") {
+		return
+	}
+
+	secrets := FindSecrets(config)
+	expected := []Secret{
+		{Pos: Pos{Line: 4, Column: 78}, Plugin: "http_poller", Option: "password"},
+		{Pos: Pos{Line: 5, Column: 21}, Plugin: "json", Option: "ssl_key_passphrase"},
+		{Pos: Pos{Line: 12, Column: 12}, Plugin: "http", Option: "user_password"},
+		{Pos: Pos{Line: 18, Column: 5}, Plugin: "elasticsearch", Option: "cloud_auth"},
+	}
+	assert.Equal(t, expected, secrets)
+	assert.Equal(t, `line 18, column 5: cloud_auth of plugin elasticsearch has a literal value, use a Logstash keystore or environment variable reference instead (e.g. cloud_auth => "${CLOUD_AUTH}")`,
+		secrets[3].String())
+}
+
+func TestIsSensitiveOption(t *testing.T) {
+	for _, name := range []string{"password", "api_key", "cloud_auth", "ssl_keystore_password", "ssl_key_passphrase", "Password"} {
+		assert.True(t, IsSensitiveOption(name), "[ %s ] expecting sensitive option", name)
+	}
+	for _, name := range []string{"user", "cloud_id", "ssl_key", "hosts"} {
+		assert.False(t, IsSensitiveOption(name), "[ %s ] expecting non sensitive option", name)
+	}
+}
@@ -0,0 +1,100 @@
+package logstash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default delimiters of RenderTemplate, distinct from the ${VAR} references resolved by Logstash and
+// from Terraform interpolations
+const (
+	DefaultLeftDelimiter  = "{{"
+	DefaultRightDelimiter = "}}"
+)
+
+// RenderTemplate replaces the variables written between the left and right delimiters (e.g. {{NAME}}),
+// anything else, like %{field} sprintf references or ${VAR} keystore references, is kept as is.
+// Every variable must be defined in vars.
+// Prefixing the left delimiter with its first character escapes it ({{{NAME}} renders {{NAME}}).
+func RenderTemplate(template, left, right string, vars map[string]string) (string, error) {
+	s := &templateScanner{input: []rune(template), pos: Pos{Line: 1, Column: 1}}
+	if len(left) == 0 || len(right) == 0 {
+		return "", s.errorf(s.pos, "delimiters cannot be empty")
+	}
+	escape := string([]rune(left)[0]) + left
+
+	var b strings.Builder
+	for !s.eof() {
+		switch {
+		case s.hasPrefix(escape):
+			s.consume(escape)
+			b.WriteString(left)
+		case s.hasPrefix(left):
+			pos := s.pos
+			s.consume(left)
+			var name strings.Builder
+			for !s.hasPrefix(right) {
+				if s.eof() {
+					return "", s.errorf(pos, "expected %q to close variable, got end of input", right)
+				}
+				name.WriteRune(s.next())
+			}
+			s.consume(right)
+			key := strings.TrimSpace(name.String())
+			value, ok := vars[key]
+			if !ok {
+				return "", s.errorf(pos, "undefined variable %q", key)
+			}
+			b.WriteString(value)
+		default:
+			b.WriteRune(s.next())
+		}
+	}
+	return b.String(), nil
+}
+
+// templateScanner walks through a template, keeping track of the position for error messages
+type templateScanner struct {
+	input  []rune
+	offset int
+	pos    Pos
+}
+
+func (s *templateScanner) eof() bool {
+	return s.offset >= len(s.input)
+}
+
+func (s *templateScanner) next() rune {
+	r := s.input[s.offset]
+	s.offset++
+	if r == '\n' {
+		s.pos.Line++
+		s.pos.Column = 1
+	} else {
+		s.pos.Column++
+	}
+	return r
+}
+
+func (s *templateScanner) hasPrefix(prefix string) bool {
+	p := []rune(prefix)
+	if s.offset+len(p) > len(s.input) {
+		return false
+	}
+	for i, r := range p {
+		if s.input[s.offset+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *templateScanner) consume(prefix string) {
+	for range []rune(prefix) {
+		s.next()
+	}
+}
+
+func (s *templateScanner) errorf(pos Pos, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
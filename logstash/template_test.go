@@ -0,0 +1,49 @@
+package logstash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]string{"CLOUD_ID": "my-cloud", "PORT": "5044"}
+	tests := []struct {
+		template    string
+		left, right string
+		expected    string
+	}{
+		{`index => "filebeat-%{+yyyy.MM.dd}"`, "${", "}", `index => "filebeat-%{+yyyy.MM.dd}"`},
+		{`cloud_id => "${CLOUD_ID}" port => ${ PORT }`, "${", "}", `cloud_id => "my-cloud" port => 5044`},
+		{`cloud_auth => "$${CLOUD_AUTH}"`, "${", "}", `cloud_auth => "${CLOUD_AUTH}"`},
+		{`$ {} ${PORT}$`, "${", "}", `$ {} 5044$`},
+		{`id => "<<CLOUD_ID>>" auth => "${CLOUD_AUTH}"`, "<<", ">>", `id => "my-cloud" auth => "${CLOUD_AUTH}"`},
+		{`é ${PORT} ü`, "${", "}", `é 5044 ü`},
+		{`id => "{{CLOUD_ID}}" auth => "${CLOUD_AUTH}" x => "{{{PORT}}"`, DefaultLeftDelimiter, DefaultRightDelimiter, `id => "my-cloud" auth => "${CLOUD_AUTH}" x => "{{PORT}}"`},
+	}
+
+	for _, test := range tests {
+		rendered, err := RenderTemplate(test.template, test.left, test.right, vars)
+		if assert.Nil(t, err, "[ %s ] expecting nil error", test.template) {
+			assert.Equal(t, test.expected, rendered, "[ %s ] unexpected rendering", test.template)
+		}
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		left     string
+		expected string
+	}{
+		{"input {\n  beats { port => ${PORT} }\n}", "${", `line 2, column 19: undefined variable "PORT"`},
+		{"input { ${CLOUD_ID", "${", `line 1, column 9: expected "}" to close variable, got end of input`},
+		{"input {}", "", `line 1, column 1: delimiters cannot be empty`},
+		{"input {\n  beats { port => ${PORT }\n}", "${", `line 2, column 19: undefined variable "PORT"`},
+	}
+
+	for _, test := range tests {
+		_, err := RenderTemplate(test.template, test.left, "}", map[string]string{"CLOUD_ID": "my-cloud"})
+		assert.EqualError(t, err, test.expected, "[ %s ] unexpected error", test.template)
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// byteSizeRegexp matches Logstash byte sizes (e.g. 1gb, 1.5 kb), the unit is mandatory
+var byteSizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([kmgtp]?b|[kmgtp])$`)
+
+var byteSizeMultipliers = map[string]float64{
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+// ParseByteSize returns the number of bytes of a Logstash byte size (e.g. 1gb, 512mb), units are case insensitive
+func ParseByteSize(s string) (int64, error) {
+	matches := byteSizeRegexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid byte size %q, expected a number followed by a unit (b, kb, mb, gb, tb or pb)", s)
+	}
+	number, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %s", s, err)
+	}
+	size := number * byteSizeMultipliers[matches[2][:1]]
+	// float64(math.MaxInt64) is 2^63, which does not fit in an int64 either
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: larger than %d bytes", s, int64(math.MaxInt64))
+	}
+	return int64(size), nil
+}
+
+// NormalizeByteSize returns the byte size as accepted by Logstash: lower case, without spaces (e.g. 1 GB -> 1gb)
+func NormalizeByteSize(s string) (string, error) {
+	if _, err := ParseByteSize(s); err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.Join(strings.Fields(s), "")), nil
+}
+
+// IsByteSize is a SchemaValidateFunc which tests if the provided value
+// is of type string and a valid byte size
+func IsByteSize(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	if _, err := ParseByteSize(v); err != nil {
+		errors = append(errors, fmt.Errorf("%s: %s", k, err))
+	}
+
+	return warnings, errors
+}
+
+// SuppressEquivalentByteSize is a SchemaDiffSuppressFunc ignoring changes between equal byte sizes (e.g. 1gb and 1024MB)
+func SuppressEquivalentByteSize(k, old, new string, d *schema.ResourceData) bool {
+	oldSize, err := ParseByteSize(old)
+	if err != nil {
+		return false
+	}
+	newSize, err := ParseByteSize(new)
+	if err != nil {
+		return false
+	}
+	return oldSize == newSize
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1b", 1},
+		{"64mb", 64 << 20},
+		{"1gb", 1 << 30},
+		{"1024mb", 1 << 30},
+		{"1GB", 1 << 30},
+		{"1 gb", 1 << 30},
+		{" 1Gb ", 1 << 30},
+		{"1.5kb", 1536},
+		{"4k", 4096},
+		{"2tb", 2 << 40},
+		{"1pb", 1 << 50},
+		{"8191pb", 8191 << 50},
+	}
+
+	for _, test := range tests {
+		size, err := ParseByteSize(test.input)
+		assert.NilError(t, err, test.input)
+		assert.Equal(t, test.expected, size, test.input)
+	}
+
+	for _, input := range []string{"", "1024", "gb", "1 g b", "-1gb", "1xb", "1,5gb"} {
+		_, err := ParseByteSize(input)
+		assert.ErrorContains(t, err, "invalid byte size", input)
+	}
+
+	// Sizes which do not fit in an int64
+	for _, input := range []string{"8192pb", "99999999pb", "9223372036854775808b"} {
+		_, err := ParseByteSize(input)
+		assert.ErrorContains(t, err, "larger than 9223372036854775807 bytes", input)
+	}
+}
+
+func TestNormalizeByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1gb", "1gb"},
+		{"1GB", "1gb"},
+		{" 512 Mb ", "512mb"},
+	}
+
+	for _, test := range tests {
+		normalized, err := NormalizeByteSize(test.input)
+		assert.NilError(t, err, test.input)
+		assert.Equal(t, test.expected, normalized, test.input)
+	}
+
+	_, err := NormalizeByteSize("1 gigabyte")
+	assert.ErrorContains(t, err, "invalid byte size")
+}
+
+func TestSuppressEquivalentByteSize(t *testing.T) {
+	tests := []struct {
+		old, new   string
+		suppressed bool
+	}{
+		{"1gb", "1gb", true},
+		{"1gb", "1024mb", true},
+		{"1gb", "1 GB", true},
+		{"1gb", "2gb", false},
+		{"", "1gb", false},
+		{"1gb", "invalid", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.suppressed, SuppressEquivalentByteSize("queue_max_bytes", test.old, test.new, nil), test.old+" -> "+test.new)
+	}
+}
+
+func TestValidationIsByteSize(t *testing.T) {
+	runTestCases(t, []testCase{
+		{
+			val: "1gb",
+			f:   IsByteSize,
+		},
+		{
+			val:         "1024",
+			f:           IsByteSize,
+			expectedErr: regexp.MustCompile("invalid byte size \"1024\""),
+		},
+	})
+}
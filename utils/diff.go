@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strings"
+)
+
+// LineDiff returns the line by line difference between from and to, removed lines are prefixed
+// by "- ", added lines by "+ " and common lines by "  ". It returns an empty string when both have the same lines.
+func LineDiff(from, to string) string {
+	if from == to {
+		return ""
+	}
+	a, b := lines(from), lines(to)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			changed = true
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		// Only the final line feed differs
+		return ""
+	}
+	return sb.String()
+}
+
+// lines splits s in lines, an empty string having none
+func lines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
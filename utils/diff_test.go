@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		from, to string
+		expected string
+	}{
+		{"input { stdin {} }", "input { stdin {} }", ""},
+		{"a\nb\nc", "a\nc", "  a\n- b\n  c\n"},
+		{"a\nc\n", "a\nb\nc\n", "  a\n+ b\n  c\n"},
+		{"a\nb", "a\nB", "  a\n- b\n+ B\n"},
+		{"", "a\nb\n", "+ a\n+ b\n"},
+		{"a", "", "- a\n"},
+		{"a\n", "a", ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, LineDiff(test.from, test.to), test.from+" -> "+test.to)
+	}
+}
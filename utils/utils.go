@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -76,3 +78,63 @@ func StringInSlice(valid []string, ignoreCase bool) schema.SchemaValidateFunc {
 		return warnings, errors
 	}
 }
+
+// IsRFC3339Time returns a SchemaValidateFunc which tests if the provided value
+// is of type string and a valid RFC3339 timestamp
+func IsRFC3339Time(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	if _, err := time.Parse(time.RFC3339, v); err != nil {
+		errors = append(errors, fmt.Errorf("expected %s to be a valid RFC3339 date, got %s: %+v", k, v, err))
+	}
+
+	return warnings, errors
+}
+
+// All returns a SchemaValidateFunc which runs every validator, returning all their warnings and errors
+func All(validators ...schema.SchemaValidateFunc) schema.SchemaValidateFunc {
+	return func(i interface{}, k string) (warnings []string, errors []error) {
+		for _, validator := range validators {
+			ws, es := validator(i, k)
+			warnings = append(warnings, ws...)
+			errors = append(errors, es...)
+		}
+		return warnings, errors
+	}
+}
+
+// IsValidRegExp returns a SchemaValidateFunc which tests if the provided value
+// is of type string and a valid regular expression
+func IsValidRegExp(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	if _, err := regexp.Compile(v); err != nil {
+		errors = append(errors, fmt.Errorf("%s: %s", k, err))
+	}
+
+	return warnings, errors
+}
+
+// StringIsNotEmpty is a SchemaValidateFunc which tests if the provided value
+// is of type string and is not empty
+func StringIsNotEmpty(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	if len(v) == 0 {
+		errors = append(errors, fmt.Errorf("expected %s not to be an empty string", k))
+	}
+
+	return warnings, errors
+}
@@ -71,6 +71,76 @@ func TestValidationStringInSlice(t *testing.T) {
 	})
 }
 
+func TestValidationIsRFC3339Time(t *testing.T) {
+	runTestCases(t, []testCase{
+		{
+			val: "2020-10-12T09:38:19.546Z",
+			f:   IsRFC3339Time,
+		},
+		{
+			val: "2020-10-12T09:38:19+02:00",
+			f:   IsRFC3339Time,
+		},
+		{
+			val:         "2020-10-12",
+			f:           IsRFC3339Time,
+			expectedErr: regexp.MustCompile("expected [\\w]+ to be a valid RFC3339 date, got 2020-10-12"),
+		},
+		{
+			val:         1,
+			f:           IsRFC3339Time,
+			expectedErr: regexp.MustCompile("expected type of [\\w]+ to be string"),
+		},
+	})
+}
+
+func TestValidationIsValidRegExp(t *testing.T) {
+	runTestCases(t, []testCase{
+		{
+			val: "^filebeat-.*$",
+			f:   IsValidRegExp,
+		},
+		{
+			val:         "filebeat-(",
+			f:           IsValidRegExp,
+			expectedErr: regexp.MustCompile("missing closing \\)"),
+		},
+	})
+}
+
+func TestValidationStringIsNotEmpty(t *testing.T) {
+	runTestCases(t, []testCase{
+		{
+			val: "${",
+			f:   StringIsNotEmpty,
+		},
+		{
+			val:         "",
+			f:           StringIsNotEmpty,
+			expectedErr: regexp.MustCompile("expected test_property not to be an empty string"),
+		},
+	})
+}
+
+func TestValidationAll(t *testing.T) {
+	runTestCases(t, []testCase{
+		{
+			val: "^filebeat-",
+			f:   All(StringIsNotEmpty, IsValidRegExp),
+		},
+		{
+			val:         "",
+			f:           All(StringIsNotEmpty, IsValidRegExp),
+			expectedErr: regexp.MustCompile("expected test_property not to be an empty string"),
+		},
+		{
+			val:         "filebeat-(",
+			f:           All(StringIsNotEmpty, IsValidRegExp),
+			expectedErr: regexp.MustCompile("missing closing \\)"),
+		},
+	})
+}
+
 func runTestCases(t *testing.T, cases []testCase) {
 	matchErr := func(errs []error, r *regexp.Regexp) bool {
 		// err must match one provided